@@ -1,16 +1,101 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
 	"github.com/davecgh/go-spew/spew"
+	"github.com/nathj07/go-resourcesync/cmd/internal/output"
 	"github.com/nathj07/go-resourcesync/core"
-	"io/ioutil"
-	"os"
 )
 
-var jsonFile = flag.String("file", "", "--file full path to a JSON file conforming to FastSync article schema")
+var (
+	jsonFile = flag.String("file", "", "--file full path to a JSON file conforming to FastSync article schema, "+
+		"either a single article, newline delimited articles or an array of them")
+	skipHeavy = flag.Bool("skip-heavy", false, "--skip-heavy, if set will not decode the full text or raw record XML")
+	skipBad   = flag.Bool("skip-bad", false, "--skip-bad, if set will log and skip malformed articles rather than stopping")
+	dedup     = flag.Bool("dedup", false, "--dedup, if set will write a canonical record for each paper in place of its duplicate articles")
+	citations = flag.String("citations", "", "--citations, if set, writes the citation graph between the articles instead: "+
+		"graphml or csv, an edge list")
+	checkIDs = flag.Bool("check-ids", false, "--check-ids, if set will log the DOIs, ISSNs and other identifiers that are not valid")
+	format   = flag.String("output", output.Text, "--output is the format written to stdout: "+strings.Join(output.Formats, ", "))
+	export   = flag.String("export", "", "--export, if set, writes the articles in a bibliographic format instead: "+
+		"bibtex, ris, csl-json or dc")
+)
+
+// exporters are the bibliographic formats offered by --export
+var exporters = map[string]func(w io.Writer, items ...core.Citable) error{
+	"bibtex":   core.ExportBibTeX,
+	"ris":      core.ExportRIS,
+	"csl-json": core.ExportCSLJSON,
+	"dc":       core.ExportDublinCore,
+}
+
+// graphWriters are the formats offered by --citations
+var graphWriters = map[string]func(g *core.CitationGraph, w io.Writer) error{
+	"graphml": (*core.CitationGraph).WriteGraphML,
+	"csv":     (*core.CitationGraph).WriteEdgeCSV,
+}
+
+const (
+	kindFSArticle = "fsArticle"
+	kindCluster   = "cluster"
+)
+
+// fsArticleRecord is a parsed FastSync article, the fields of the FSArticle follow the kind
+type fsArticleRecord struct {
+	Kind string `json:"kind"`
+	*core.FSArticle
+}
+
+func (rec fsArticleRecord) Header() []string {
+	return []string{"coreId", "doi", "title", "year", "publisher", "downloadUrl"}
+}
+
+func (rec fsArticleRecord) Row() []string {
+	return []string{rec.CoreID, rec.DOI, rec.Title, strconv.Itoa(rec.Year), rec.Publisher, rec.DownloadURL}
+}
+
+// String keeps the text output as the full dump of the Go struct
+func (rec fsArticleRecord) String() string {
+	return strings.TrimSuffix(spew.Sdump(rec.FSArticle), "\n")
+}
+
+// clusterRecord is a paper found by --dedup, with the articles it was found in
+type clusterRecord struct {
+	Kind string `json:"kind"`
+	*core.Cluster
+}
+
+func (rec clusterRecord) Header() []string {
+	return []string{"coreId", "doi", "title", "year", "duplicates"}
+}
+
+func (rec clusterRecord) Row() []string {
+	var dups []string
+	for _, src := range rec.Sources {
+		if src.CoreID != rec.Canonical.CoreID {
+			dups = append(dups, src.CoreID)
+		}
+	}
+	return []string{rec.Canonical.CoreID, rec.Canonical.DOI, rec.Canonical.Title, strconv.Itoa(rec.Canonical.Year),
+		strings.Join(dups, " ")}
+}
+
+func (rec clusterRecord) String() string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "%s %s", rec.Canonical.CoreID, rec.Canonical.Title)
+	for _, src := range rec.Sources {
+		if src.MatchedBy != "" {
+			fmt.Fprintf(sb, "\n  %s matched %s by %s", src.CoreID, src.MatchedWith, src.MatchedBy)
+		}
+	}
+	return sb.String()
+}
 
 func main() {
 	flag.Usage = func() {
@@ -24,20 +109,93 @@ Flags:`)
 		fmt.Fprintf(os.Stderr, "--file is a mandatory argument, please specify an absolute path to the JSON file \n")
 		os.Exit(1)
 	}
-	readJsonFile()
+	out, err := output.New(*format, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	exporter, ok := exporters[*export]
+	if *export != "" && !ok {
+		fmt.Fprintf(os.Stderr, "unknown export format %q\n", *export)
+		os.Exit(1)
+	}
+	graphWriter, ok := graphWriters[*citations]
+	if *citations != "" && !ok {
+		fmt.Fprintf(os.Stderr, "unknown citation graph format %q\n", *citations)
+		os.Exit(1)
+	}
+	if err := readJsonFile(out, exporter, graphWriter); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to write output: %v\n", err)
+		os.Exit(4)
+	}
 }
 
-func readJsonFile() {
-	data, err := ioutil.ReadFile(*jsonFile)
+// readJsonFile prints each article in the file as it is decoded, or with --dedup each paper once all are read. If
+// exporter is set the articles, or papers, are written with it instead, and if graphWriter is set the citation graph
+// between them.
+func readJsonFile(out *output.Printer, exporter func(w io.Writer, items ...core.Citable) error,
+	graphWriter func(g *core.CitationGraph, w io.Writer) error) error {
+	f, err := os.Open(*jsonFile)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Unable to read file: %q - %v\n", *jsonFile, err)
 		os.Exit(2)
 	}
-	res := &core.FSArticle{}
-	err = json.Unmarshal(data, res)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Unable to parse JSON: %v\n", err)
-		os.Exit(3)
+	defer f.Close()
+	fr := core.NewFSArticleReader(f)
+	fr.SkipBad = *skipBad
+	if *skipHeavy {
+		fr.SkipFields = core.HeavyFSArticleFields
+	}
+	fr.OnSkip = func(err *core.RecordError) {
+		fmt.Fprintf(os.Stderr, "Skipping article: %v\n", err)
+	}
+	var items []core.Citable
+	d := core.NewDeduplicator()
+	g := core.NewCitationGraph()
+	for {
+		res, err := fr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			out.Flush()
+			fmt.Fprintf(os.Stderr, "Unable to parse JSON: %v\n", err)
+			os.Exit(3)
+		}
+		if *checkIDs {
+			for _, idErr := range res.CheckIdentifiers().Invalid {
+				fmt.Fprintf(os.Stderr, "Article %s: %v\n", res.CoreID, idErr)
+			}
+		}
+		switch {
+		case graphWriter != nil:
+			g.Add(res)
+		case *dedup:
+			d.Add(res)
+		case exporter != nil:
+			items = append(items, res)
+		default:
+			if err := out.Print(fsArticleRecord{Kind: kindFSArticle, FSArticle: res}); err != nil {
+				return err
+			}
+		}
+	}
+	if graphWriter != nil {
+		return graphWriter(g, os.Stdout)
+	}
+	if *dedup {
+		for _, c := range d.Clusters() {
+			if exporter != nil {
+				items = append(items, c.Canonical)
+				continue
+			}
+			if err := out.Print(clusterRecord{Kind: kindCluster, Cluster: c}); err != nil {
+				return err
+			}
+		}
+	}
+	if exporter != nil {
+		return exporter(os.Stdout, items...)
 	}
-	spew.Dump(res)
+	return out.Flush()
 }
@@ -0,0 +1,120 @@
+// package output writes the records produced by the command line tools in the format chosen with --output.
+// The record schemas are documented in cmd/OUTPUT.md; every JSON record carries a kind field naming its schema.
+
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// The supported output formats
+const (
+	Text  = "text"  // human readable, the default
+	JSON  = "json"  // a single JSON array holding every record
+	JSONL = "jsonl" // one JSON record per line, written as it is produced
+	Table = "table" // aligned columns with a header row for each kind of record
+)
+
+// Formats lists the supported output formats, for use in flag descriptions
+var Formats = []string{Text, JSON, JSONL, Table}
+
+// Record is a single item of output. Records are marshalled to JSON as they are, so they must include their kind.
+type Record interface {
+	// Header names the columns of the table format
+	Header() []string
+	// Row is the record in table form, one value per column of the Header
+	Row() []string
+	// String is the record in text form
+	String() string
+}
+
+// Printer writes records in one of the supported formats. JSON and table output are buffered until Flush.
+type Printer struct {
+	format  string
+	w       io.Writer
+	records []Record
+}
+
+// New returns a Printer writing in the given format to w
+func New(format string, w io.Writer) (*Printer, error) {
+	for _, f := range Formats {
+		if f == format {
+			return &Printer{format: format, w: w}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported output format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+// Print outputs a record
+func (p *Printer) Print(r Record) error {
+	switch p.format {
+	case Text:
+		_, err := fmt.Fprintln(p.w, r.String())
+		return err
+	case JSONL:
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(p.w, "%s\n", data)
+		return err
+	default:
+		p.records = append(p.records, r)
+		return nil
+	}
+}
+
+// Flush writes any buffered records, it must be called once all the records have been printed
+func (p *Printer) Flush() error {
+	switch p.format {
+	case JSON:
+		records := p.records
+		if records == nil {
+			records = []Record{}
+		}
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case Table:
+		return p.writeTable()
+	}
+	return nil
+}
+
+// writeTable writes the records as aligned columns, a header row starts each run of records of the same kind
+func (p *Printer) writeTable() error {
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	previous := ""
+	for i, r := range p.records {
+		header := strings.Join(r.Header(), "\t")
+		if header != previous {
+			if i > 0 {
+				// a blank line separates the tables, the columns of one do not line up with the next
+				if err := tw.Flush(); err != nil {
+					return err
+				}
+				fmt.Fprintln(p.w)
+			}
+			fmt.Fprintln(tw, strings.ToUpper(header))
+			previous = header
+		}
+		row := r.Row()
+		for j, v := range row {
+			row[j] = cell(v)
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// cell makes a value safe to place in a table, which is a single line of tab separated values
+func cell(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(v)
+}
@@ -0,0 +1,92 @@
+package output
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrinter(t *testing.T) {
+	testData := []struct {
+		format string
+		exp    string
+	}{
+		{format: Text, exp: "a is 1\nb is 2\nx\n"},
+		{format: JSONL, exp: `{"kind":"item","name":"a","count":1}
+{"kind":"item","name":"b","count":2}
+{"kind":"summary","total":"x"}
+`},
+		{format: JSON, exp: `[
+  {
+    "kind": "item",
+    "name": "a",
+    "count": 1
+  },
+  {
+    "kind": "item",
+    "name": "b",
+    "count": 2
+  },
+  {
+    "kind": "summary",
+    "total": "x"
+  }
+]
+`},
+		{format: Table, exp: "NAME  COUNT\na     1\nb     2\n\nTOTAL\nx\n"},
+	}
+	for _, td := range testData {
+		buf := &bytes.Buffer{}
+		p, err := New(td.format, buf)
+		require.Nil(t, err, td.format)
+		require.Nil(t, p.Print(item{Kind: "item", Name: "a", Count: 1}), td.format)
+		require.Nil(t, p.Print(item{Kind: "item", Name: "b", Count: 2}), td.format)
+		require.Nil(t, p.Print(summary{Kind: "summary", Total: "x"}), td.format)
+		require.Nil(t, p.Flush(), td.format)
+		assert.Equal(t, td.exp, buf.String(), td.format)
+	}
+}
+
+func TestPrinterEmpty(t *testing.T) {
+	buf := &bytes.Buffer{}
+	p, err := New(JSON, buf)
+	require.Nil(t, err)
+	require.Nil(t, p.Flush())
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestPrinterUnknownFormat(t *testing.T) {
+	_, err := New("yaml", &bytes.Buffer{})
+	assert.NotNil(t, err)
+}
+
+func TestCell(t *testing.T) {
+	assert.Equal(t, "-", cell(""))
+	assert.Equal(t, "a b c", cell("a\tb\nc"))
+}
+
+//
+// Test Data
+//
+
+type item struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func (i item) Header() []string { return []string{"name", "count"} }
+func (i item) Row() []string    { return []string{i.Name, strconv.Itoa(i.Count)} }
+func (i item) String() string   { return i.Name + " is " + strconv.Itoa(i.Count) }
+
+type summary struct {
+	Kind  string `json:"kind"`
+	Total string `json:"total"`
+}
+
+func (s summary) Header() []string { return []string{"total"} }
+func (s summary) Row() []string    { return []string{s.Total} }
+func (s summary) String() string   { return s.Total }
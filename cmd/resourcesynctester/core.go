@@ -0,0 +1,34 @@
+package main
+
+import (
+	"log"
+
+	"github.com/nathj07/go-resourcesync/core"
+)
+
+// runCore dispatches the CORE specific commands, currently only article
+func runCore(app *app, args []string) int {
+	if len(args) == 0 || args[0] != "article" {
+		log.Printf("Usage of %s\n", commands["core"].usage)
+		return exitFailure
+	}
+	fs := newFlagSet("core")
+	apiKey := fs.String("apikey", "", "--apikey is used in requests for CORE article metadata, prefer --apikey-file or $"+
+		core.DefaultAPIKeyEnv+" as flags are visible to other users")
+	apiKeyFile := fs.String("apikey-file", "", "--apikey-file is a file holding the API key")
+	target, ok := parseTarget(fs, args[1:])
+	if !ok {
+		return exitFailure
+	}
+	app.ce.Credentials = core.EnvCredentials(core.DefaultAPIKeyEnv)
+	if *apiKeyFile != "" {
+		app.ce.Credentials = core.FileCredentials(*apiKeyFile)
+	}
+	data, err := app.ce.Process(target, *apiKey)
+	if err != nil {
+		log.Printf("failed to process CORE metadata from %q: %v\n", target, err)
+		return exitFailure
+	}
+	app.print(articleRecord{Kind: kindArticle, ArticleWrapper: data})
+	return 0
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"log"
+
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+func runDiscover(app *app, args []string) int {
+	target, ok := parseTarget(newFlagSet("discover"), args)
+	if !ok {
+		return exitFailure
+	}
+	found, err := app.rs.Discover(target)
+	if err != nil {
+		log.Printf("failed to discover the capabilities of %q: %v\n", target, err)
+		return exitFailure
+	}
+	for _, c := range found {
+		app.print(capabilityRecord{
+			Kind:           kindCapability,
+			CapabilityList: c.CapabilityList,
+			Capability:     c.Capability,
+			Loc:            c.Loc,
+		})
+	}
+	return 0
+}
+
+func runInspect(app *app, args []string) int {
+	fs := newFlagSet("inspect")
+	entries := fs.Bool("entries", false, "--entries, if set will list every entry of the document after it")
+	target, ok := parseTarget(fs, args)
+	if !ok {
+		return exitFailure
+	}
+	rd, err := app.rs.Process(target)
+	if err != nil {
+		log.Printf("Error encountered checking resourcesync: %v\n", err)
+		return exitFailure
+	}
+	app.print(newDocumentRecord(target, rd))
+	if !*entries {
+		return 0
+	}
+	if rd.RLI != nil {
+		for _, index := range rd.RLI.IndexSet {
+			app.print(newEntryRecord(resourcesync.ResourceURL{Loc: index.Loc, LastMod: index.LastMod, RSMD: index.RSMD}))
+		}
+		return 0
+	}
+	for _, ru := range rd.RL.URLSet {
+		app.print(newEntryRecord(ru))
+	}
+	return 0
+}
@@ -3,132 +3,163 @@ package main
 import (
 	"flag"
 	"fmt"
-	"github.com/nathj07/go-resourcesync/core"
 	"log"
+	"net/http"
 	"net/url"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/nathj07/go-resourcesync/cmd/internal/output"
+	"github.com/nathj07/go-resourcesync/core"
 	"github.com/nathj07/go-resourcesync/fetcher"
 	"github.com/nathj07/go-resourcesync/resourcesync"
 )
 
 var (
-	target     = flag.String("target", "", "--target=http:/example.com/resourcesync.xml")
-	targetType = flag.String("targettype", "resourcesync", "--targettype indicates if this is 'resourcesync' or 'core'")
-	follow     = flag.Bool("follow", false, "--follow indicates if resource link index sets should be followed until resource lists are reached")
-	apiKey     = flag.String("apikey", "", "--apikey is used in requests for CORE article metadata")
-	verbose    = flag.Bool("verbose", false, "--verbose, if set will print all the links discovered")
+	timeout     = flag.Duration("timeout", 30*time.Second, "--timeout limits each HTTP request, 0 for no limit")
+	concurrency = flag.Int("concurrency", 4, "--concurrency is the number of requests validate and sync make at once")
+	format      = flag.String("output", output.Text, "--output is the format written to stdout: "+strings.Join(output.Formats, ", "))
+	verbose     = flag.Bool("verbose", false, "--verbose, if set will log progress to stderr")
 )
 
+// Exit codes, a check that ran but found problems is distinguished from a failure to run
 const (
-	segmentation = "====================" // breaks up the output
-	targetCore   = "core"
+	exitFailure  = 1
+	exitProblems = 2
 )
 
+const segmentation = "====================" // breaks up the output
+
+// command is a subcommand of the tool. run is given the arguments following the command name and returns the exit
+// code, 0 on success.
+type command struct {
+	usage   string
+	summary string
+	run     func(app *app, args []string) int
+}
+
+// commands is populated in init as the commands refer back to it for their usage
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"discover": {"discover <site or source description URL>", "list the capabilities a source offers", runDiscover},
+		"inspect":  {"inspect [flags] <URL>", "fetch a ResourceSync document and describe it", runInspect},
+		"walk":     {"walk [flags] <URL>", "list every resource below a list or index", runWalk},
+		"validate": {"validate [flags] <URL>", "check a list or index, and optionally its resources, against the specification", runValidate},
+		"sync":     {"sync [flags] -dir <dir> <capability list URL>", "mirror a source to a local directory", runSync},
+		"audit":    {"audit [flags] -dir <dir> <resource list URL>", "check a local mirror matches the source", runAudit},
+		"core":     {"core article [-apikey-file <file>] <CORE article URL>", "fetch CORE article metadata", runCore},
+	}
+}
+
+// app holds what the commands share, configured from the global flags
 type app struct {
-	rs             *resourcesync.ResourceSync
-	ce             *core.Extractor
-	follow         bool
-	verbose        bool
-	indexLinkCount int
-	linkCount      int
-	startingPoint  string
-	targetType     string
-	apiKey         string
+	rs          *resourcesync.ResourceSync
+	ce          *core.Extractor
+	fetcher     fetcher.RSFetcher
+	concurrency int
+	verbose     bool
+	out         *output.Printer
 }
 
 func main() {
-	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, `Usage of %s:
-%s is a tool within the go-resourcesync client that allows you to visit a resourcesync endpoint and evaluate the response.
-Flags:
-`, os.Args[0], os.Args[0])
-		flag.PrintDefaults()
-	}
+	flag.Usage = usage
 	flag.Parse()
-
-	u, err := url.Parse(*target)
-	if err != nil || !u.IsAbs() {
-		log.Println("This tool expects a --target flag to be passed in with a valid, absolute URL.")
-		os.Exit(1)
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(exitFailure)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		log.Printf("Unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(exitFailure)
 	}
-	if *targetType == targetCore && *apiKey == "" {
-		log.Printf("When specifying the target type %q you must provide an API Key for use in requests for the metadata.\n", targetCore)
-		os.Exit(1)
+	out, err := output.New(*format, os.Stdout)
+	if err != nil {
+		log.Println(err)
+		os.Exit(exitFailure)
+	}
+	f := &fetcher.BasicRSFetcher{
+		Client: &http.Client{Timeout: *timeout},
 	}
 	app := &app{
-		rs: &resourcesync.ResourceSync{
-			Fetcher: &fetcher.BasicRSFetcher{},
-		},
+		rs: resourcesync.New(f),
 		ce: &core.Extractor{
-			Fetcher: &fetcher.BasicRSFetcher{},
+			Fetcher: f,
 		},
-		follow:        *follow,
-		verbose:       *verbose,
-		startingPoint: *target,
-		targetType:    *targetType,
-		apiKey:        *apiKey,
+		fetcher:     f,
+		concurrency: *concurrency,
+		verbose:     *verbose,
+		out:         out,
 	}
-
-	app.processTarget(*target)
-	if app.verbose {
-		log.Println("Starting point:", app.startingPoint)
-		log.Println("Index links found:", app.indexLinkCount)
-		log.Println("Resource links found:", app.linkCount)
+	if app.concurrency < 1 {
+		app.concurrency = 1
+	}
+	code := cmd.run(app, flag.Args()[1:])
+	if err := out.Flush(); err != nil {
+		log.Println(err)
+		code = exitFailure
 	}
+	os.Exit(code)
 }
 
-func (app *app) processTarget(target string) {
-	if app.targetType == targetCore {
-		// fetch and unmarshall the data
-		app.processCoreMetadata()
-		return
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage of %s:
+%s is a tool within the go-resourcesync client that allows you to visit a resourcesync endpoint and evaluate the response.
+
+	%s [global flags] <command> [command flags] <target>
+
+Commands:
+`, os.Args[0], os.Args[0], os.Args[0])
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
 	}
-	app.processResourceSync(target)
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nGlobal flags:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the flags of a command.\n", os.Args[0])
 }
 
-func (app *app) processCoreMetadata() {
-	data, err := app.ce.Process(app.startingPoint, app.apiKey)
-	if err != nil {
-		log.Printf("failed to process CORE metadata from %q: %v\n", app.startingPoint, err)
-		os.Exit(1)
+// print writes a record to stdout in the chosen output format
+func (app *app) print(r output.Record) {
+	if err := app.out.Print(r); err != nil {
+		log.Println("failed to write output:", err)
 	}
-	log.Println(data)
 }
 
-func (app *app) processResourceSync(target string) {
-	resources, err := app.checkResourceSync(target)
-	if err != nil {
-		log.Printf("Error encountered checking resourcesync: %v\n", err)
-		os.Exit(1)
+// newFlagSet creates the flag set for a command, its usage shows the command's synopsis
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of %s %s:\n", os.Args[0], commands[name].usage)
+		fs.PrintDefaults()
 	}
+	return fs
+}
 
-	log.Println("ResourceSync Data:")
-	if resources.RL != nil {
-		// this is the end of the chain, these we print, these would be the links to full text content
-		if app.verbose {
-			log.Println(resources.RL)
-		}
-		app.linkCount += len(resources.RL.URLSet)
+// parseTarget parses the command flags and returns the single target URL that must follow them
+func parseTarget(fs *flag.FlagSet, args []string) (string, bool) {
+	if err := fs.Parse(args); err != nil {
+		return "", false
 	}
-	if resources.RLI != nil {
-		for _, index := range resources.RLI.IndexSet {
-			if app.verbose {
-				log.Println("Will follow:", index)
-				log.Println(segmentation)
-			}
-			if app.follow {
-				app.processResourceSync(index.Loc)
-			}
-		}
-		if app.verbose {
-			log.Printf("Followed %d index links\n", len(resources.RLI.IndexSet))
-			log.Println(segmentation)
-		}
-		app.indexLinkCount += len(resources.RLI.IndexSet)
+	if fs.NArg() != 1 {
+		log.Printf("%s expects a single target URL\n", fs.Name())
+		fs.Usage()
+		return "", false
 	}
-}
-func (app *app) checkResourceSync(target string) (*resourcesync.ResourceData, error) {
-	// fetch it
-	return app.rs.Process(target)
+	target := fs.Arg(0)
+	u, err := url.Parse(target)
+	if err != nil || !u.IsAbs() {
+		log.Printf("%s expects a valid, absolute URL, got %q\n", fs.Name(), target)
+		return "", false
+	}
+	return target, true
 }
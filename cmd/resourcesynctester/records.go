@@ -0,0 +1,322 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nathj07/go-resourcesync/core"
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+// The kind of each record, naming its schema in cmd/OUTPUT.md
+const (
+	kindCapability      = "capability"
+	kindDocument        = "document"
+	kindEntry           = "entry"
+	kindWalkSummary     = "walkSummary"
+	kindProblem         = "problem"
+	kindValidateSummary = "validateSummary"
+	kindSyncSummary     = "syncSummary"
+	kindAuditResult     = "auditResult"
+	kindAuditSummary    = "auditSummary"
+	kindArticle         = "article"
+)
+
+// capabilityRecord is a capability found by discover
+type capabilityRecord struct {
+	Kind           string `json:"kind"`
+	CapabilityList string `json:"capabilityList"`
+	Capability     string `json:"capability"`
+	Loc            string `json:"loc"`
+}
+
+func (rec capabilityRecord) Header() []string {
+	return []string{"capability", "loc", "capabilityList"}
+}
+
+func (rec capabilityRecord) Row() []string {
+	return []string{rec.Capability, rec.Loc, rec.CapabilityList}
+}
+
+func (rec capabilityRecord) String() string {
+	return fmt.Sprintf("%s\t%s", rec.Capability, rec.Loc)
+}
+
+type linkRecord struct {
+	Rel  string `json:"rel"`
+	Href string `json:"href"`
+	Type string `json:"type,omitempty"`
+}
+
+// documentRecord describes a list or index, as fetched by inspect
+type documentRecord struct {
+	Kind       string       `json:"kind"`
+	URL        string       `json:"url"`
+	Capability string       `json:"capability"`
+	Index      bool         `json:"index"`
+	At         string       `json:"at,omitempty"`
+	Completed  string       `json:"completed,omitempty"`
+	From       string       `json:"from,omitempty"`
+	Until      string       `json:"until,omitempty"`
+	Links      []linkRecord `json:"links"`
+	EntryCount int          `json:"entryCount"`
+}
+
+func newDocumentRecord(target string, rd *resourcesync.ResourceData) documentRecord {
+	md := rd.Metadata()
+	rec := documentRecord{
+		Kind:       kindDocument,
+		URL:        target,
+		Capability: md.Capability,
+		Index:      rd.RLI != nil,
+		At:         md.At,
+		Completed:  md.Completed,
+		From:       md.From,
+		Until:      md.Until,
+		Links:      []linkRecord{},
+	}
+	links := []resourcesync.RSLN{}
+	if rd.RLI != nil {
+		links = rd.RLI.RSLink
+		rec.EntryCount = len(rd.RLI.IndexSet)
+	} else if rd.RL != nil {
+		links = rd.RL.RSLink
+		rec.EntryCount = len(rd.RL.URLSet)
+	}
+	for _, ln := range links {
+		rec.Links = append(rec.Links, linkRecord{Rel: ln.Rel, Href: strings.TrimSpace(ln.Href), Type: ln.Type})
+	}
+	return rec
+}
+
+func (rec documentRecord) Header() []string {
+	return []string{"url", "capability", "index", "at", "completed", "from", "until", "entryCount"}
+}
+
+func (rec documentRecord) Row() []string {
+	return []string{rec.URL, rec.Capability, strconv.FormatBool(rec.Index), rec.At, rec.Completed, rec.From, rec.Until,
+		strconv.Itoa(rec.EntryCount)}
+}
+
+func (rec documentRecord) String() string {
+	sb := &strings.Builder{}
+	kind := rec.Capability
+	if rec.Index {
+		kind += " index"
+	}
+	fmt.Fprintf(sb, "URL: %s\nType: %s\n", rec.URL, kind)
+	for _, attr := range []struct{ name, value string }{
+		{"At", rec.At}, {"Completed", rec.Completed}, {"From", rec.From}, {"Until", rec.Until},
+	} {
+		if attr.value != "" {
+			fmt.Fprintf(sb, "%s: %s\n", attr.name, attr.value)
+		}
+	}
+	for _, ln := range rec.Links {
+		fmt.Fprintf(sb, "Link: %s %s\n", ln.Rel, ln.Href)
+	}
+	fmt.Fprintf(sb, "Entries: %d", rec.EntryCount)
+	return sb.String()
+}
+
+// entryRecord is an entry of a list or index
+type entryRecord struct {
+	Kind        string `json:"kind"`
+	Loc         string `json:"loc"`
+	LastMod     string `json:"lastmod,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Length      string `json:"length,omitempty"`
+	Hash        string `json:"hash,omitempty"`
+	Change      string `json:"change,omitempty"`
+	DateTime    string `json:"datetime,omitempty"`
+	DescribedBy string `json:"describedBy,omitempty"`
+}
+
+func newEntryRecord(ru resourcesync.ResourceURL) entryRecord {
+	rec := entryRecord{
+		Kind:     kindEntry,
+		Loc:      strings.TrimSpace(ru.Loc),
+		LastMod:  strings.TrimSpace(ru.LastMod),
+		Type:     ru.RSMD.Type,
+		Length:   ru.RSMD.Length,
+		Hash:     ru.RSMD.Hash,
+		Change:   ru.RSMD.Change,
+		DateTime: ru.RSMD.DateTime,
+	}
+	if ln, ok := ru.Link(core.RelDescribedBy); ok {
+		rec.DescribedBy = strings.TrimSpace(ln.Href)
+	}
+	return rec
+}
+
+func (rec entryRecord) Header() []string {
+	return []string{"loc", "lastmod", "type", "length", "hash", "change", "datetime", "describedBy"}
+}
+
+func (rec entryRecord) Row() []string {
+	return []string{rec.Loc, rec.LastMod, rec.Type, rec.Length, rec.Hash, rec.Change, rec.DateTime, rec.DescribedBy}
+}
+
+func (rec entryRecord) String() string {
+	fields := []string{rec.Loc}
+	for _, v := range []string{rec.Change, rec.DateTime, rec.LastMod, rec.Type, rec.Length, rec.Hash} {
+		if v != "" {
+			fields = append(fields, v)
+		}
+	}
+	return strings.Join(fields, "\t")
+}
+
+// walkSummaryRecord closes the output of walk
+type walkSummaryRecord struct {
+	Kind    string `json:"kind"`
+	Target  string `json:"target"`
+	Seen    int    `json:"seen"`
+	Matched int    `json:"matched"`
+}
+
+func (rec walkSummaryRecord) Header() []string {
+	return []string{"target", "seen", "matched"}
+}
+
+func (rec walkSummaryRecord) Row() []string {
+	return []string{rec.Target, strconv.Itoa(rec.Seen), strconv.Itoa(rec.Matched)}
+}
+
+func (rec walkSummaryRecord) String() string {
+	return fmt.Sprintf("%s\nStarting point: %s\nResource links found: %d\nResource links matching filters: %d",
+		segmentation, rec.Target, rec.Seen, rec.Matched)
+}
+
+// problemRecord is a problem found by validate
+type problemRecord struct {
+	Kind    string `json:"kind"`
+	URL     string `json:"url"`
+	Problem string `json:"problem"`
+}
+
+func (rec problemRecord) Header() []string {
+	return []string{"url", "problem"}
+}
+
+func (rec problemRecord) Row() []string {
+	return []string{rec.URL, rec.Problem}
+}
+
+func (rec problemRecord) String() string {
+	return fmt.Sprintf("%s: %s", rec.URL, rec.Problem)
+}
+
+// validateSummaryRecord closes the output of validate
+type validateSummaryRecord struct {
+	Kind             string `json:"kind"`
+	Target           string `json:"target"`
+	Documents        int    `json:"documents"`
+	ResourcesChecked int    `json:"resourcesChecked"`
+	Problems         int    `json:"problems"`
+}
+
+func (rec validateSummaryRecord) Header() []string {
+	return []string{"target", "documents", "resourcesChecked", "problems"}
+}
+
+func (rec validateSummaryRecord) Row() []string {
+	return []string{rec.Target, strconv.Itoa(rec.Documents), strconv.Itoa(rec.ResourcesChecked), strconv.Itoa(rec.Problems)}
+}
+
+func (rec validateSummaryRecord) String() string {
+	return fmt.Sprintf("%s\nDocuments checked: %d\nResources checked: %d\nProblems found: %d",
+		segmentation, rec.Documents, rec.ResourcesChecked, rec.Problems)
+}
+
+// syncSummaryRecord is the outcome of sync
+type syncSummaryRecord struct {
+	Kind           string   `json:"kind"`
+	CapabilityList string   `json:"capabilityList"`
+	FullSync       bool     `json:"fullSync"`
+	Downloaded     int      `json:"downloaded"`
+	Skipped        int      `json:"skipped"`
+	Deleted        int      `json:"deleted"`
+	Errors         []string `json:"errors"`
+}
+
+func (rec syncSummaryRecord) Header() []string {
+	return []string{"capabilityList", "fullSync", "downloaded", "skipped", "deleted", "errors"}
+}
+
+func (rec syncSummaryRecord) Row() []string {
+	return []string{rec.CapabilityList, strconv.FormatBool(rec.FullSync), strconv.Itoa(rec.Downloaded),
+		strconv.Itoa(rec.Skipped), strconv.Itoa(rec.Deleted), strconv.Itoa(len(rec.Errors))}
+}
+
+func (rec syncSummaryRecord) String() string {
+	return fmt.Sprintf("Full sync: %t\nDownloaded: %d\nSkipped: %d\nDeleted: %d\nErrors: %d",
+		rec.FullSync, rec.Downloaded, rec.Skipped, rec.Deleted, len(rec.Errors))
+}
+
+// auditResultRecord is a resource audit found to be missing, changed or extra
+type auditResultRecord struct {
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	Loc    string `json:"loc,omitempty"`
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+func (rec auditResultRecord) Header() []string {
+	return []string{"status", "key", "loc", "reason"}
+}
+
+func (rec auditResultRecord) Row() []string {
+	return []string{rec.Status, rec.Key, rec.Loc, rec.Reason}
+}
+
+func (rec auditResultRecord) String() string {
+	return fmt.Sprintf("%s: %s %s %s", rec.Status, rec.Key, rec.Loc, rec.Reason)
+}
+
+// auditSummaryRecord closes the output of audit
+type auditSummaryRecord struct {
+	Kind     string   `json:"kind"`
+	Target   string   `json:"target"`
+	Complete bool     `json:"complete"`
+	Matching int      `json:"matching"`
+	Missing  int      `json:"missing"`
+	Changed  int      `json:"changed"`
+	Extra    int      `json:"extra"`
+	Errors   []string `json:"errors"`
+}
+
+func (rec auditSummaryRecord) Header() []string {
+	return []string{"target", "complete", "matching", "missing", "changed", "extra", "errors"}
+}
+
+func (rec auditSummaryRecord) Row() []string {
+	return []string{rec.Target, strconv.FormatBool(rec.Complete), strconv.Itoa(rec.Matching), strconv.Itoa(rec.Missing),
+		strconv.Itoa(rec.Changed), strconv.Itoa(rec.Extra), strconv.Itoa(len(rec.Errors))}
+}
+
+func (rec auditSummaryRecord) String() string {
+	return fmt.Sprintf("%s\nMatching: %d\nMissing: %d\nChanged: %d\nExtra: %d\nErrors: %d", segmentation,
+		rec.Matching, rec.Missing, rec.Changed, rec.Extra, len(rec.Errors))
+}
+
+// articleRecord is CORE article metadata, the fields of the ArticleWrapper follow the kind
+type articleRecord struct {
+	Kind string `json:"kind"`
+	*core.ArticleWrapper
+}
+
+func (rec articleRecord) Header() []string {
+	return []string{"id", "title", "year", "publisher", "downloadUrl"}
+}
+
+func (rec articleRecord) Row() []string {
+	a := rec.Data
+	return []string{a.ID, a.Title, strconv.Itoa(a.Year), a.Publisher, a.DownloadURL}
+}
+
+func (rec articleRecord) String() string {
+	return rec.ArticleWrapper.String()
+}
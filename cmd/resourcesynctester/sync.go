@@ -0,0 +1,100 @@
+package main
+
+import (
+	"log"
+
+	"github.com/nathj07/go-resourcesync/destination"
+	"github.com/nathj07/go-resourcesync/mirror"
+)
+
+func runSync(app *app, args []string) int {
+	fs := newFlagSet("sync")
+	dir := fs.String("dir", "", "--dir is the local directory the source is mirrored to")
+	ff := addFilterFlags(fs)
+	target, ok := parseTarget(fs, args)
+	if !ok {
+		return exitFailure
+	}
+	if *dir == "" {
+		log.Println("sync requires the --dir to mirror to")
+		return exitFailure
+	}
+	filters, err := ff.filters()
+	if err != nil {
+		log.Println(err)
+		return exitFailure
+	}
+	m := mirror.New(app.fetcher, target, destination.NewLocal(*dir))
+	m.RS = app.rs
+	m.Filters = filters
+	m.Concurrency = app.concurrency
+	stats, err := m.Sync()
+	if err != nil {
+		log.Printf("failed to sync %q to %q: %v\n", target, *dir, err)
+		return exitFailure
+	}
+	rec := syncSummaryRecord{
+		Kind:           kindSyncSummary,
+		CapabilityList: target,
+		FullSync:       stats.FullSync,
+		Downloaded:     stats.Downloaded,
+		Skipped:        stats.Skipped,
+		Deleted:        stats.Deleted,
+		Errors:         []string{},
+	}
+	for _, err := range stats.Errors {
+		rec.Errors = append(rec.Errors, err.Error())
+		log.Println("error:", err)
+	}
+	app.print(rec)
+	if len(rec.Errors) > 0 {
+		return exitProblems
+	}
+	return 0
+}
+
+func runAudit(app *app, args []string) int {
+	fs := newFlagSet("audit")
+	dir := fs.String("dir", "", "--dir is the local mirror checked against the target resource list")
+	ff := addFilterFlags(fs)
+	target, ok := parseTarget(fs, args)
+	if !ok {
+		return exitFailure
+	}
+	if *dir == "" {
+		log.Println("audit requires the --dir of the mirror to check")
+		return exitFailure
+	}
+	filters, err := ff.filters()
+	if err != nil {
+		log.Println(err)
+		return exitFailure
+	}
+	report, err := mirror.Audit(app.rs, target, destination.NewLocal(*dir), filters...)
+	if err != nil {
+		log.Printf("failed to audit %q against %q: %v\n", *dir, target, err)
+		return exitFailure
+	}
+	for _, d := range report.Details {
+		app.print(auditResultRecord{Kind: kindAuditResult, Status: d.Status, Loc: d.Loc, Key: d.Key, Reason: d.Reason})
+	}
+	summary := auditSummaryRecord{
+		Kind:     kindAuditSummary,
+		Target:   target,
+		Complete: report.Complete(),
+		Matching: report.Matching,
+		Missing:  report.Missing,
+		Changed:  report.Changed,
+		Extra:    report.Extra,
+		Errors:   []string{},
+	}
+	for _, err := range report.Errors {
+		summary.Errors = append(summary.Errors, err.Error())
+		log.Println("error:", err)
+	}
+	app.print(summary)
+	if !report.Complete() {
+		return exitProblems
+	}
+	return 0
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+func runValidate(app *app, args []string) int {
+	fs := newFlagSet("validate")
+	resources := fs.Bool("resources", false, "--resources, if set will also fetch every resource and check its length and hash")
+	ff := addFilterFlags(fs)
+	target, ok := parseTarget(fs, args)
+	if !ok {
+		return exitFailure
+	}
+	filters, err := ff.filters()
+	if err != nil {
+		log.Println(err)
+		return exitFailure
+	}
+	rd, err := app.rs.Process(target)
+	if err != nil {
+		log.Printf("failed to fetch %q: %v\n", target, err)
+		return exitFailure
+	}
+
+	summary := validateSummaryRecord{Kind: kindValidateSummary, Target: target, Documents: 1}
+	report := func(url string, err error) {
+		summary.Problems++
+		app.print(problemRecord{Kind: kindProblem, URL: url, Problem: err.Error()})
+	}
+	for _, err := range resourcesync.Validate(rd) {
+		report(target, err)
+	}
+	if rd.RLI != nil {
+		var lists []string
+		for _, index := range rd.RLI.IndexSet {
+			lists = append(lists, strings.TrimSpace(index.Loc))
+		}
+		summary.Documents += len(lists)
+		results := make([][]error, len(lists))
+		app.parallel(len(lists), func(i int) {
+			if app.verbose {
+				log.Println("Validating:", lists[i])
+			}
+			listData, err := app.rs.Process(lists[i])
+			if err != nil {
+				results[i] = []error{err}
+				return
+			}
+			results[i] = resourcesync.Validate(listData)
+		})
+		for i, errs := range results {
+			for _, err := range errs {
+				report(lists[i], err)
+			}
+		}
+	}
+
+	if *resources {
+		var entries []resourcesync.ResourceURL
+		err := app.rs.WalkData(rd, func(ru resourcesync.ResourceURL) error {
+			entries = append(entries, ru)
+			return nil
+		}, filters...)
+		if err != nil {
+			log.Printf("failed to walk %q: %v\n", target, err)
+			return exitFailure
+		}
+		summary.ResourcesChecked = len(entries)
+		results := make([]error, len(entries))
+		app.parallel(len(entries), func(i int) {
+			results[i] = app.checkResource(entries[i])
+		})
+		for i, err := range results {
+			if err != nil {
+				report(strings.TrimSpace(entries[i].Loc), err)
+			}
+		}
+	}
+
+	app.print(summary)
+	if summary.Problems > 0 {
+		return exitProblems
+	}
+	return 0
+}
+
+// checkResource fetches the resource and compares it with the length and hash published for it
+func (app *app) checkResource(ru resourcesync.ResourceURL) error {
+	if ru.RSMD.Change == resourcesync.ChangeDeleted {
+		return nil
+	}
+	data, status, err := app.fetcher.Fetch(strings.TrimSpace(ru.Loc))
+	if err != nil {
+		return fmt.Errorf("%d: %v", status, err)
+	}
+	if length, err := strconv.Atoi(strings.TrimSpace(ru.RSMD.Length)); err == nil && length != len(data) {
+		return fmt.Errorf("length %d, expected %d", len(data), length)
+	}
+	if expected, ok := resourcesync.PreferredHash(ru.RSMD.Hash); ok {
+		got, err := resourcesync.ComputeHash(bytes.NewReader(data), expected)
+		if err != nil {
+			return err
+		}
+		if got != expected {
+			return fmt.Errorf("hash %s, expected %s", got, expected)
+		}
+	}
+	return nil
+}
+
+// parallel calls fn for each index from 0 to n-1, running up to the configured concurrency at once
+func (app *app) parallel(n int, fn func(i int)) {
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < app.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+// filterFlags are the flags shared by the commands that accept resource filters
+type filterFlags struct {
+	types     *string
+	prefix    *string
+	pattern   *string
+	from      *string
+	until     *string
+	minLength *int64
+	maxLength *int64
+}
+
+func addFilterFlags(fs *flag.FlagSet) *filterFlags {
+	return &filterFlags{
+		types:     fs.String("type", "", "--type only includes resources of these comma separated MIME types, such as application/pdf"),
+		prefix:    fs.String("prefix", "", "--prefix only includes resources whose URL starts with this prefix"),
+		pattern:   fs.String("pattern", "", "--pattern only includes resources whose URL matches this regular expression"),
+		from:      fs.String("from", "", "--from only includes resources modified at or after this W3C datetime"),
+		until:     fs.String("until", "", "--until only includes resources modified at or before this W3C datetime"),
+		minLength: fs.Int64("min-length", 0, "--min-length only includes resources of at least this many bytes"),
+		maxLength: fs.Int64("max-length", 0, "--max-length only includes resources of at most this many bytes"),
+	}
+}
+
+// filters builds the resource filters asked for on the command line
+func (ff *filterFlags) filters() ([]resourcesync.Filter, error) {
+	var filters []resourcesync.Filter
+	if *ff.types != "" {
+		filters = append(filters, resourcesync.MIMEType(strings.Split(*ff.types, ",")...))
+	}
+	if *ff.prefix != "" {
+		filters = append(filters, resourcesync.URLPrefix(*ff.prefix))
+	}
+	if *ff.pattern != "" {
+		re, err := regexp.Compile(*ff.pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --pattern: %v", err)
+		}
+		filters = append(filters, resourcesync.URLPattern(re))
+	}
+	if *ff.from != "" || *ff.until != "" {
+		var from, until time.Time
+		var err error
+		if *ff.from != "" {
+			if from, err = resourcesync.ParseDateTime(*ff.from); err != nil {
+				return nil, fmt.Errorf("invalid --from: %v", err)
+			}
+		}
+		if *ff.until != "" {
+			if until, err = resourcesync.ParseDateTime(*ff.until); err != nil {
+				return nil, fmt.Errorf("invalid --until: %v", err)
+			}
+		}
+		filters = append(filters, resourcesync.ModifiedBetween(from, until))
+	}
+	if *ff.minLength > 0 || *ff.maxLength > 0 {
+		filters = append(filters, resourcesync.LengthBetween(*ff.minLength, *ff.maxLength))
+	}
+	return filters, nil
+}
+
+func runWalk(app *app, args []string) int {
+	fs := newFlagSet("walk")
+	ff := addFilterFlags(fs)
+	target, ok := parseTarget(fs, args)
+	if !ok {
+		return exitFailure
+	}
+	filters, err := ff.filters()
+	if err != nil {
+		log.Println(err)
+		return exitFailure
+	}
+	summary := walkSummaryRecord{Kind: kindWalkSummary, Target: target}
+	match := resourcesync.All(filters...)
+	err = app.rs.Walk(target, func(ru resourcesync.ResourceURL) error {
+		summary.Seen++
+		if !match(ru) {
+			return nil
+		}
+		summary.Matched++
+		app.print(newEntryRecord(ru))
+		return nil
+	})
+	if err != nil {
+		log.Printf("failed to walk %q: %v\n", target, err)
+		return exitFailure
+	}
+	app.print(summary)
+	return 0
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/nathj07/go-resourcesync/fetcher"
+	"net/http"
+	"net/url"
 	"reflect"
 	"strings"
 )
@@ -77,19 +79,47 @@ type RepositoryDoc struct {
 	DepositedDate   int64 `json:"depositedDate"`
 }
 
+// Extractor fetches and decodes CORE article metadata
 type Extractor struct {
 	Fetcher fetcher.RSFetcher
+	// Credentials, optional, supply the API key when none is passed to Process
+	Credentials Credentials
+	// KeyHeader, optional, is the header the API key is sent in when the Fetcher implements fetcher.HeaderFetcher,
+	// keeping the key out of the URL. Otherwise the key is added to the query as apiKey.
+	KeyHeader string
 }
 
 // Process makes a request to the CORE API and unmarshals the returned data into a Go struct.
+// If apiKey is empty the key is taken from the Extractor's Credentials. The key never appears in a returned error.
 func (ce *Extractor) Process(target, apiKey string) (*ArticleWrapper, error) {
-	data, status, err := ce.Fetcher.Fetch(target + "?apiKey=" + apiKey)
+	key, err := resolveAPIKey(apiKey, ce.Credentials)
 	if err != nil {
-		return nil, fmt.Errorf("%d: %v", status, err)
+		return nil, err
+	}
+	data, status, err := ce.fetchWithKey(target, key)
+	if err != nil {
+		return nil, redact(fmt.Errorf("%d: %v", status, err), key)
 	}
 	return ce.ExtractArticle(data)
 }
 
+// fetchWithKey fetches the target, sending the key in the KeyHeader if possible and as a query parameter otherwise
+func (ce *Extractor) fetchWithKey(target, key string) ([]byte, int, error) {
+	if hf, ok := ce.Fetcher.(fetcher.HeaderFetcher); ok && ce.KeyHeader != "" {
+		header := http.Header{}
+		header.Set(ce.KeyHeader, key)
+		return hf.FetchWithHeader(target, header)
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid target %q: %v", target, err)
+	}
+	query := u.Query()
+	query.Set("apiKey", key)
+	u.RawQuery = query.Encode()
+	return ce.Fetcher.Fetch(u.String())
+}
+
 // ExtractArticle is a convenience method around unmarshaling the CORE article metadata
 func (ce *Extractor) ExtractArticle(rawData []byte) (*ArticleWrapper, error) {
 	res := &ArticleWrapper{}
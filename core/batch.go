@@ -0,0 +1,91 @@
+package core
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Defaults for GetArticles, used when the Client's BatchSize or Concurrency are not set
+const (
+	DefaultBatchSize   = 100
+	DefaultConcurrency = 4
+)
+
+// ErrArticleNotFound is the error given by GetArticles for an ID CORE returned no work for
+var ErrArticleNotFound = errors.New("article not found")
+
+// ArticleResult is the outcome of fetching one ID with GetArticles, exactly one of Work and Err is set
+type ArticleResult struct {
+	ID   string
+	Work *Work
+	Err  error
+}
+
+// GetArticles fetches the works with the given CORE IDs. The IDs are split into batches of BatchSize, each fetched
+// with a single search request, with up to Concurrency requests made at once.
+// There is a result for every ID, in the order given. An ID CORE has no work for gets ErrArticleNotFound, and when a
+// request fails every ID in its batch gets that error, so one bad batch does not lose the rest.
+func (c *Client) GetArticles(ids []string) []ArticleResult {
+	results := make([]ArticleResult, len(ids))
+	size := c.BatchSize
+	if size < 1 {
+		size = DefaultBatchSize
+	}
+	workers := c.Concurrency
+	if workers < 1 {
+		workers = DefaultConcurrency
+	}
+	// a batch holds the positions in ids, so that repeated IDs are each given a result
+	work := make(chan []int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range work {
+				c.getBatch(ids, batch, results)
+			}
+		}()
+	}
+	var batch []int
+	for i := range ids {
+		batch = append(batch, i)
+		if len(batch) == size {
+			work <- batch
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		work <- batch
+	}
+	close(work)
+	wg.Wait()
+	return results
+}
+
+// getBatch fetches the IDs at the given positions in a single request, each worker writes only its own positions of
+// results so no locking is needed
+func (c *Client) getBatch(ids []string, batch []int, results []ArticleResult) {
+	var terms []string
+	for _, i := range batch {
+		terms = append(terms, "id:"+strconv.Quote(strings.TrimSpace(ids[i])))
+	}
+	found := map[string]*Work{}
+	it := c.SearchWorks(Search{Query: strings.Join(terms, " OR "), PageSize: len(batch)})
+	for it.Next() {
+		found[strconv.FormatInt(it.Work().ID, 10)] = it.Work()
+	}
+	for _, i := range batch {
+		results[i].ID = ids[i]
+		switch w, ok := found[strings.TrimSpace(ids[i])]; {
+		case it.Err() != nil:
+			results[i].Err = it.Err()
+		case ok:
+			results[i].Work = w
+		default:
+			results[i].Err = ErrArticleNotFound
+		}
+	}
+}
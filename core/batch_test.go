@@ -0,0 +1,121 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetArticles(t *testing.T) {
+	fb := &fakeBatch{}
+	server := httptest.NewServer(fb)
+	defer server.Close()
+	c := &Client{BaseURL: server.URL, BatchSize: 3, Concurrency: 2}
+
+	var ids []string
+	for i := 1; i <= 10; i++ {
+		ids = append(ids, strconv.Itoa(i))
+	}
+	ids = append(ids, "404", "1")
+	results := c.GetArticles(ids)
+	require.Len(t, results, len(ids))
+	for i, res := range results {
+		assert.Equal(t, ids[i], res.ID)
+		switch res.ID {
+		case "404":
+			assert.Equal(t, ErrArticleNotFound, res.Err)
+			assert.Nil(t, res.Work)
+		default:
+			require.Nil(t, res.Err, res.ID)
+			assert.Equal(t, res.ID, strconv.FormatInt(res.Work.ID, 10))
+		}
+	}
+	assert.Equal(t, 4, fb.requests, "12 IDs in batches of 3")
+	assert.Equal(t, []int{3, 3, 3, 3}, fb.sizes)
+	assert.True(t, fb.maxActive <= 2, "at most Concurrency requests at once")
+}
+
+func TestGetArticlesBatchFailure(t *testing.T) {
+	fb := &fakeBatch{fail: "5"}
+	server := httptest.NewServer(fb)
+	defer server.Close()
+	c := &Client{BaseURL: server.URL, BatchSize: 2}
+
+	results := c.GetArticles([]string{"1", "2", "5", "6", "7"})
+	require.Len(t, results, 5)
+	for _, res := range results {
+		switch res.ID {
+		case "5", "6":
+			require.NotNil(t, res.Err, res.ID)
+			assert.Equal(t, http.StatusInternalServerError, res.Err.(*APIError).StatusCode, res.ID)
+		default:
+			assert.Nil(t, res.Err, res.ID)
+			assert.NotNil(t, res.Work, res.ID)
+		}
+	}
+}
+
+func TestGetArticlesEmpty(t *testing.T) {
+	c := &Client{BaseURL: "http://localhost:0"}
+	assert.Empty(t, c.GetArticles(nil))
+}
+
+//
+// Test Data
+//
+
+var idTerm = regexp.MustCompile(`id:"(\d+)"`)
+
+// fakeBatch answers searches for lists of IDs, returning a work for each ID other than 404
+type fakeBatch struct {
+	fail      string // an ID whose batch fails with a 500
+	mu        sync.Mutex
+	requests  int
+	sizes     []int
+	active    int
+	maxActive int
+}
+
+func (fb *fakeBatch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fb.mu.Lock()
+	fb.requests++
+	fb.active++
+	if fb.active > fb.maxActive {
+		fb.maxActive = fb.active
+	}
+	fb.mu.Unlock()
+	defer func() {
+		fb.mu.Lock()
+		fb.active--
+		fb.mu.Unlock()
+	}()
+	time.Sleep(10 * time.Millisecond) // long enough for the requests to overlap
+
+	matches := idTerm.FindAllStringSubmatch(r.URL.Query().Get("q"), -1)
+	fb.mu.Lock()
+	fb.sizes = append(fb.sizes, len(matches))
+	fb.mu.Unlock()
+	res := searchResponse{Results: []Work{}}
+	for _, m := range matches {
+		if m[1] == fb.fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if m[1] == "404" {
+			continue
+		}
+		id, _ := strconv.ParseInt(m[1], 10, 64)
+		res.Results = append(res.Results, Work{ID: id, Title: fmt.Sprintf("Work %d", id)})
+	}
+	res.TotalHits = len(res.Results)
+	json.NewEncoder(w).Encode(res)
+}
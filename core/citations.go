@@ -0,0 +1,283 @@
+package core
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// How a reference was resolved to a CORE article, as given in its Citation
+const (
+	ResolvedByCoreID = "coreId" // the cites list of the reference named the article
+	ResolvedByDOI    = "doi"
+	ResolvedByTitle  = "title" // the normalised title matched that of a single article
+)
+
+// CitationGraph is a directed graph of the citations between the articles of a fast sync stream, built from the
+// references of their enrichments. A reference is resolved to an article added to the graph by the CORE IDs in its
+// cites list, then by its DOI and then by its title, normalised to lower case words. Titles shared by more than one
+// article, or of fewer than three words, are not used. References are resolved once all the articles are added, so
+// an article may cite one added after it.
+//
+// Only the identifiers, titles and references of each article are held, so the graph of a large dump fits in memory.
+type CitationGraph struct {
+	nodes    []CitationNode
+	index    map[string]int // CORE ID to node
+	refs     [][]Reference  // the references of each node
+	byDOI    map[string]string
+	byTitle  map[string]string // normalised title to CORE ID, empty where the title is ambiguous
+	resolved bool
+	edges    []Citation
+	missing  []Citation
+}
+
+// CitationNode is an article of a CitationGraph
+type CitationNode struct {
+	CoreID string
+	Title  string
+	DOI    string
+	Year   int
+}
+
+// Citation is an edge of a CitationGraph, the citing article From referencing the cited article To. For an
+// unresolved reference To and ResolvedBy are empty.
+type Citation struct {
+	From        string
+	To          string
+	ResolvedBy  string // one of the ResolvedBy constants
+	ReferenceID int64
+	DOI         string // of the reference
+	Title       string // of the reference
+}
+
+// NewCitationGraph returns an empty graph, as is the zero value
+func NewCitationGraph() *CitationGraph {
+	return &CitationGraph{
+		index:   map[string]int{},
+		byDOI:   map[string]string{},
+		byTitle: map[string]string{},
+	}
+}
+
+// Add adds an article and its references to the graph. Articles without a CORE ID, or with one already added, are
+// ignored.
+func (g *CitationGraph) Add(fs *FSArticle) {
+	r := fs.Record()
+	if r.CoreID == "" {
+		return
+	}
+	if _, ok := g.index[r.CoreID]; ok {
+		return
+	}
+	if g.index == nil {
+		g.index = map[string]int{}
+		g.byDOI = map[string]string{}
+		g.byTitle = map[string]string{}
+	}
+	g.resolved = false
+	g.index[r.CoreID] = len(g.nodes)
+	g.nodes = append(g.nodes, CitationNode{CoreID: r.CoreID, Title: r.Title, DOI: r.DOI, Year: r.Year})
+	g.refs = append(g.refs, r.References)
+	if doi := doiFrom([]string{r.DOI}); doi != "" {
+		if _, ok := g.byDOI[doi]; !ok {
+			g.byDOI[doi] = r.CoreID
+		}
+	}
+	if title := citationTitle(r.Title); title != "" {
+		if _, ok := g.byTitle[title]; ok {
+			g.byTitle[title] = ""
+		} else {
+			g.byTitle[title] = r.CoreID
+		}
+	}
+}
+
+// Nodes returns the articles of the graph in the order they were added
+func (g *CitationGraph) Nodes() []CitationNode {
+	return g.nodes
+}
+
+// Edges returns the resolved citations, in the order of the citing articles and their references. A reference
+// resolving to the citing article itself, or to an article already cited by it, is left out.
+func (g *CitationGraph) Edges() []Citation {
+	g.resolve()
+	return g.edges
+}
+
+// Unresolved returns the references that could not be resolved to an article of the graph
+func (g *CitationGraph) Unresolved() []Citation {
+	g.resolve()
+	return g.missing
+}
+
+// Cites returns the CORE IDs of the articles cited by an article
+func (g *CitationGraph) Cites(coreID string) []string {
+	var res []string
+	for _, e := range g.Edges() {
+		if e.From == coreID {
+			res = append(res, e.To)
+		}
+	}
+	return res
+}
+
+// CitedBy returns the CORE IDs of the articles citing an article
+func (g *CitationGraph) CitedBy(coreID string) []string {
+	var res []string
+	for _, e := range g.Edges() {
+		if e.To == coreID {
+			res = append(res, e.From)
+		}
+	}
+	return res
+}
+
+// resolve matches the references of every article to the articles of the graph
+func (g *CitationGraph) resolve() {
+	if g.resolved {
+		return
+	}
+	g.resolved = true
+	g.edges, g.missing = nil, nil
+	for i, node := range g.nodes {
+		seen := map[string]bool{node.CoreID: true}
+		for _, ref := range g.refs[i] {
+			c := Citation{From: node.CoreID, ReferenceID: ref.ID, DOI: ref.DOI, Title: ref.Title}
+			c.To, c.ResolvedBy = g.resolveReference(ref)
+			switch {
+			case c.To == "":
+				g.missing = append(g.missing, c)
+			case !seen[c.To]:
+				seen[c.To] = true
+				g.edges = append(g.edges, c)
+			}
+		}
+	}
+}
+
+func (g *CitationGraph) resolveReference(ref Reference) (string, string) {
+	for _, id := range ref.Cites {
+		coreID := strconv.FormatInt(id, 10)
+		if _, ok := g.index[coreID]; ok {
+			return coreID, ResolvedByCoreID
+		}
+	}
+	// keyed as in Add, so that a DOI which cannot be normalised still matches
+	if doi := doiFrom([]string{ref.DOI}); doi != "" {
+		if coreID, ok := g.byDOI[doi]; ok {
+			return coreID, ResolvedByDOI
+		}
+	}
+	if title := citationTitle(ref.Title); title != "" && g.byTitle[title] != "" {
+		return g.byTitle[title], ResolvedByTitle
+	}
+	return "", ""
+}
+
+// citationTitle normalises a title for resolving references, or gives "" for one too short to be relied on
+func citationTitle(title string) string {
+	title = normaliseTitle(title)
+	if len(strings.Fields(title)) < minFuzzyTitleTokens {
+		return ""
+	}
+	return title
+}
+
+// WriteEdgeCSV writes the resolved citations as CSV with a header, one citation per row: the citing CORE ID, the
+// cited CORE ID and how the reference was resolved
+func (g *CitationGraph) WriteEdgeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"source", "target", "resolvedBy"}); err != nil {
+		return err
+	}
+	for _, e := range g.Edges() {
+		if err := cw.Write([]string{e.From, e.To, e.ResolvedBy}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// The namespace of GraphML
+const nsGraphML = "http://graphml.graphdrawing.org/xmlns"
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph as GraphML, for tools such as Gephi, Cytoscape or NetworkX. Nodes are identified by
+// CORE ID and carry the title, DOI and year of the article, edges carry how the reference was resolved.
+func (g *CitationGraph) WriteGraphML(w io.Writer) error {
+	doc := graphML{
+		XMLNS: nsGraphML,
+		Keys: []graphMLKey{
+			{ID: "title", For: "node", Name: "title", Type: "string"},
+			{ID: "doi", For: "node", Name: "doi", Type: "string"},
+			{ID: "year", For: "node", Name: "year", Type: "int"},
+			{ID: "resolvedBy", For: "edge", Name: "resolvedBy", Type: "string"},
+		},
+		Graph: graphMLGraph{ID: "citations", EdgeDefault: "directed"},
+	}
+	for _, n := range g.nodes {
+		node := graphMLNode{ID: n.CoreID}
+		for _, d := range []graphMLData{{"title", n.Title}, {"doi", n.DOI}, {"year", yearString(n.Year)}} {
+			if d.Value != "" {
+				node.Data = append(node.Data, d)
+			}
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+	for _, e := range g.Edges() {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: e.From,
+			Target: e.To,
+			Data:   []graphMLData{{Key: "resolvedBy", Value: e.ResolvedBy}},
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
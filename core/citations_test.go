@@ -0,0 +1,161 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCitationGraph(t *testing.T) {
+	g := NewCitationGraph()
+	for _, a := range testCitationArticles {
+		g.Add(a)
+	}
+	assert.Equal(t, []CitationNode{
+		{CoreID: "1", Title: "Intimate Partner Violence and Coercive Control", DOI: "10.1056/one", Year: 2010},
+		{CoreID: "2", Title: "Disadvantaged Teenagers and Violence"},
+		{CoreID: "3", Title: "Standing on My Own Two Feet", Year: 2012},
+		{CoreID: "4", Title: "Editorial"},
+		{CoreID: "5", Title: "Editorial"},
+	}, g.Nodes())
+	assert.Equal(t, []Citation{
+		{From: "1", To: "2", ResolvedBy: ResolvedByCoreID, ReferenceID: 10, Title: "Teenagers"},
+		{From: "1", To: "3", ResolvedBy: ResolvedByTitle, ReferenceID: 11, Title: "Standing on my own two feet."},
+		{From: "2", To: "1", ResolvedBy: ResolvedByDOI, ReferenceID: 20, DOI: "https://doi.org/10.1056/ONE"},
+		{From: "3", To: "1", ResolvedBy: ResolvedByTitle, ReferenceID: 30, Title: "INTIMATE PARTNER VIOLENCE AND COERCIVE CONTROL."},
+	}, g.Edges())
+	assert.Equal(t, []Citation{
+		{From: "1", ReferenceID: 13, DOI: "10.1056/unknown"},
+		{From: "3", ReferenceID: 32, Title: "Editorial"},
+	}, g.Unresolved())
+	assert.Equal(t, []string{"2", "3"}, g.Cites("1"))
+	assert.Equal(t, []string{"2", "3"}, g.CitedBy("1"))
+	assert.Nil(t, g.CitedBy("5"))
+}
+
+func TestCitationGraphIgnored(t *testing.T) {
+	g := NewCitationGraph()
+	g.Add(&FSArticle{Title: "No CORE ID"})
+	g.Add(&FSArticle{CoreID: "1", Title: "First"})
+	g.Add(&FSArticle{CoreID: "1", Title: "Repeated"})
+	assert.Equal(t, []CitationNode{{CoreID: "1", Title: "First"}}, g.Nodes())
+	assert.Nil(t, g.Edges())
+}
+
+func TestCitationGraphAddAfterResolve(t *testing.T) {
+	g := NewCitationGraph()
+	g.Add(&FSArticle{CoreID: "1", Enrichments: FSArticleEnrichment{References: []FSReference{{ID: 1, Cites: []int{2}}}}})
+	assert.Empty(t, g.Edges())
+	g.Add(&FSArticle{CoreID: "2"})
+	assert.Equal(t, []Citation{{From: "1", To: "2", ResolvedBy: ResolvedByCoreID, ReferenceID: 1}}, g.Edges())
+}
+
+func TestCitationGraphZeroValue(t *testing.T) {
+	g := &CitationGraph{}
+	g.Add(&FSArticle{CoreID: "1", DOI: "doi:10.1/ABC"})
+	g.Add(&FSArticle{CoreID: "2", Enrichments: FSArticleEnrichment{References: []FSReference{{ID: 1, DOI: "10.1/abc"}}}})
+	g.Add(&FSArticle{CoreID: "3", Enrichments: FSArticleEnrichment{References: []FSReference{{ID: 2, DOI: "https://doi.org/10.1/Abc"}}}})
+	assert.Equal(t, []Citation{
+		{From: "2", To: "1", ResolvedBy: ResolvedByDOI, ReferenceID: 1, DOI: "10.1/abc"},
+		{From: "3", To: "1", ResolvedBy: ResolvedByDOI, ReferenceID: 2, DOI: "https://doi.org/10.1/Abc"},
+	}, g.Edges())
+}
+
+func TestCitationGraphExport(t *testing.T) {
+	g := NewCitationGraph()
+	for _, a := range testCitationArticles[:3] {
+		g.Add(a)
+	}
+	buf := &bytes.Buffer{}
+	require.Nil(t, g.WriteEdgeCSV(buf))
+	assert.Equal(t, expEdgeCSV, buf.String())
+	assert.NotNil(t, g.WriteEdgeCSV(failingWriter{}))
+
+	buf.Reset()
+	require.Nil(t, g.WriteGraphML(buf))
+	assert.Equal(t, expGraphML, buf.String())
+	assert.NotNil(t, g.WriteGraphML(failingWriter{}))
+}
+
+//
+// Test Data
+//
+
+var testCitationArticles = []*FSArticle{
+	{
+		CoreID: "1",
+		Title:  "Intimate Partner Violence and Coercive Control",
+		DOI:    "doi:10.1056/ONE",
+		Year:   2010,
+		Enrichments: FSArticleEnrichment{References: []FSReference{
+			{ID: 10, Title: "Teenagers", Cites: []int{99, 2}},
+			{ID: 11, Title: "Standing on my own two feet."},
+			{ID: 12, Title: "Repeated", Cites: []int{3}},
+			{ID: 13, DOI: "10.1056/unknown"},
+			{ID: 14, Title: "Self citation", Cites: []int{1}},
+		}},
+	},
+	{
+		CoreID: "2",
+		Title:  "Disadvantaged Teenagers and Violence",
+		Enrichments: FSArticleEnrichment{References: []FSReference{
+			{ID: 20, DOI: "https://doi.org/10.1056/ONE"},
+		}},
+	},
+	{
+		CoreID: "3",
+		Title:  "Standing on My Own Two Feet",
+		Year:   2012,
+		Enrichments: FSArticleEnrichment{References: []FSReference{
+			{ID: 30, Title: "INTIMATE PARTNER VIOLENCE AND COERCIVE CONTROL."},
+			{ID: 31, DOI: "10.1056/one"},
+			{ID: 32, Title: "Editorial"},
+		}},
+	},
+	{CoreID: "4", Title: "Editorial"},
+	{CoreID: "5", Title: "Editorial"},
+}
+
+var expEdgeCSV = `source,target,resolvedBy
+1,2,coreId
+1,3,title
+2,1,doi
+3,1,title
+`
+
+var expGraphML = `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="title" for="node" attr.name="title" attr.type="string"></key>
+  <key id="doi" for="node" attr.name="doi" attr.type="string"></key>
+  <key id="year" for="node" attr.name="year" attr.type="int"></key>
+  <key id="resolvedBy" for="edge" attr.name="resolvedBy" attr.type="string"></key>
+  <graph id="citations" edgedefault="directed">
+    <node id="1">
+      <data key="title">Intimate Partner Violence and Coercive Control</data>
+      <data key="doi">10.1056/one</data>
+      <data key="year">2010</data>
+    </node>
+    <node id="2">
+      <data key="title">Disadvantaged Teenagers and Violence</data>
+    </node>
+    <node id="3">
+      <data key="title">Standing on My Own Two Feet</data>
+      <data key="year">2012</data>
+    </node>
+    <edge source="1" target="2">
+      <data key="resolvedBy">coreId</data>
+    </edge>
+    <edge source="1" target="3">
+      <data key="resolvedBy">title</data>
+    </edge>
+    <edge source="2" target="1">
+      <data key="resolvedBy">doi</data>
+    </edge>
+    <edge source="3" target="1">
+      <data key="resolvedBy">title</data>
+    </edge>
+  </graph>
+</graphml>
+`
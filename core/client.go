@@ -0,0 +1,246 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL is the root of the CORE API v3
+const DefaultBaseURL = "https://api.core.ac.uk/v3"
+
+// The headers CORE uses to report rate limiting, the standard Retry-After is also honoured
+const (
+	headerRateLimitRemaining  = "X-RateLimit-Remaining"
+	headerRateLimitRetryAfter = "X-RateLimit-Retry-After"
+)
+
+// DefaultMaxRetries is the number of times a request refused with 429 Too Many Requests is retried
+const DefaultMaxRetries = 3
+
+// Client makes requests to the CORE API v3, authenticating with the API key as a Bearer token.
+// The v2 style article URLs handled by Extractor.Process are not supported by v3 keys. The key is never included in
+// a returned error.
+//
+// The rate limit headers CORE returns are honoured: once the remaining allowance is used up further requests wait
+// until the time CORE gives, and requests refused with 429 are retried after waiting. A Client is safe for
+// concurrent use and the wait is shared by every request it makes.
+type Client struct {
+	BaseURL     string
+	APIKey      string
+	Credentials Credentials  // optional, supplies the key when APIKey is empty
+	HTTPClient  *http.Client // optional, http.DefaultClient is used if nil
+	MaxRetries  int          // for requests refused with 429, NewClient sets DefaultMaxRetries
+	BatchSize   int          // the number of IDs GetArticles fetches in each request, DefaultBatchSize if 0
+	Concurrency int          // the number of requests GetArticles makes at once, DefaultConcurrency if 0
+
+	mu      sync.Mutex
+	waitTil time.Time // no request is made before this time
+	now     func() time.Time
+	sleep   func(time.Duration)
+}
+
+// NewClient is the simplest way to instantiate a ready to use Client against the public CORE API
+func NewClient(apiKey string) *Client {
+	return &Client{
+		BaseURL:    DefaultBaseURL,
+		APIKey:     apiKey,
+		HTTPClient: http.DefaultClient,
+		MaxRetries: DefaultMaxRetries,
+	}
+}
+
+// APIError is returned for any response from CORE other than 200, carrying the message CORE gave where there is one
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("CORE API returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("CORE API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// GetWork fetches the work with the given CORE ID
+func (c *Client) GetWork(id string) (*Work, error) {
+	res := &Work{}
+	if err := c.get("/works/"+url.PathEscape(id), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetOutput fetches the output with the given CORE ID
+func (c *Client) GetOutput(id string) (*Output, error) {
+	res := &Output{}
+	if err := c.get("/outputs/"+url.PathEscape(id), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetDataProvider fetches the data provider with the given CORE ID
+func (c *Client) GetDataProvider(id string) (*DataProvider, error) {
+	res := &DataProvider{}
+	if err := c.get("/data-providers/"+url.PathEscape(id), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetJournal fetches a journal by its identifier, such as "issn:0028-0836"
+func (c *Client) GetJournal(id string) (*Journal, error) {
+	res := &Journal{}
+	if err := c.get("/journals/"+url.PathEscape(id), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// get requests the path below BaseURL and decodes the JSON response into v
+func (c *Client) get(p string, query url.Values, v interface{}) error {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	target := strings.TrimSuffix(base, "/") + p
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	for attempt := 0; ; attempt++ {
+		c.waitForRateLimit()
+		status, header, data, err := c.do(target)
+		if err != nil {
+			return err
+		}
+		c.recordRateLimit(status, header, attempt)
+		if status == http.StatusTooManyRequests && attempt < c.MaxRetries {
+			continue
+		}
+		if status != http.StatusOK {
+			return newAPIError(status, data)
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			return fmt.Errorf("failed to decode response from %q: %v", target, err)
+		}
+		return nil
+	}
+}
+
+// do makes a single authenticated GET request, returning the status, headers and body of the response
+func (c *Client) do(target string) (int, http.Header, []byte, error) {
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	key := c.APIKey
+	if key == "" && c.Credentials != nil {
+		if key, err = c.Credentials.APIKey(); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, nil, redact(fmt.Errorf("error making GET request against: %q: %v", target, err), key)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return resp.StatusCode, resp.Header, data, nil
+}
+
+// waitForRateLimit blocks until CORE is expected to accept requests again
+func (c *Client) waitForRateLimit() {
+	c.mu.Lock()
+	wait := c.waitTil.Sub(c.timeNow())
+	c.mu.Unlock()
+	if wait <= 0 {
+		return
+	}
+	if c.sleep != nil {
+		c.sleep(wait)
+		return
+	}
+	time.Sleep(wait)
+}
+
+// recordRateLimit notes when requests may resume, if the response shows the allowance is used up. For a 429 with
+// no indication of when to retry the wait doubles with each attempt, starting at a second.
+func (c *Client) recordRateLimit(status int, header http.Header, attempt int) {
+	limited := status == http.StatusTooManyRequests
+	if remaining, err := strconv.Atoi(header.Get(headerRateLimitRemaining)); err == nil && remaining <= 0 {
+		limited = true
+	}
+	if !limited {
+		return
+	}
+	now := c.timeNow()
+	until, ok := retryAfter(header, now)
+	if !ok {
+		if status != http.StatusTooManyRequests {
+			return
+		}
+		until = now.Add(time.Second << uint(attempt))
+	}
+	c.mu.Lock()
+	if until.After(c.waitTil) {
+		c.waitTil = until
+	}
+	c.mu.Unlock()
+}
+
+func (c *Client) timeNow() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// retryAfter reads when to retry from the CORE or standard header, given either as a number of seconds or a time
+func retryAfter(header http.Header, now time.Time) (time.Time, bool) {
+	for _, name := range []string{headerRateLimitRetryAfter, "Retry-After"} {
+		v := strings.TrimSpace(header.Get(name))
+		if v == "" {
+			continue
+		}
+		if secs, err := strconv.Atoi(v); err == nil {
+			return now.Add(time.Duration(secs) * time.Second), true
+		}
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// newAPIError builds the error for a failed request, CORE normally gives the reason as {"message": "..."}
+func newAPIError(status int, body []byte) *APIError {
+	msg := struct {
+		Message string `json:"message"`
+	}{}
+	if json.Unmarshal(body, &msg) != nil {
+		msg.Message = strings.TrimSpace(string(body))
+	}
+	return &APIError{StatusCode: status, Message: msg.Message}
+}
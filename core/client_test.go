@@ -0,0 +1,143 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test_key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message": "Invalid API key"}`)
+			return
+		}
+		body, ok := testClientResponses[r.URL.EscapedPath()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+	c := NewClient("test_key")
+	c.BaseURL = server.URL + "/v3"
+
+	work, err := c.GetWork("42")
+	require.Nil(t, err)
+	assert.Equal(t, expWork, work)
+
+	output, err := c.GetOutput("7")
+	require.Nil(t, err)
+	assert.Equal(t, int64(7), output.ID)
+	assert.Equal(t, DataProviderRef{ID: 3, Name: "Open Research Online"}, output.DataProvider)
+
+	dp, err := c.GetDataProvider("3")
+	require.Nil(t, err)
+	assert.Equal(t, "Open Research Online", dp.Name)
+	assert.Equal(t, "GB", dp.Location.CountryCode)
+	assert.True(t, dp.Enabled)
+
+	journal, err := c.GetJournal("issn:0028-0836")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"0028-0836", "1476-4687"}, journal.Identifiers)
+}
+
+func TestClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/works/unauthorized":
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message": "Invalid API key"}`)
+		case "/v3/works/html":
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, "Bad Gateway\n")
+		case "/v3/works/invalid":
+			fmt.Fprint(w, `{"id": "not a number"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c := &Client{BaseURL: server.URL + "/v3/", APIKey: "test_key"}
+
+	testData := []struct {
+		tag    string
+		id     string
+		status int
+		exp    string
+	}{
+		{tag: "json message", id: "unauthorized", status: http.StatusUnauthorized, exp: "CORE API returned 401: Invalid API key"},
+		{tag: "plain body", id: "html", status: http.StatusBadGateway, exp: "CORE API returned 502: Bad Gateway"},
+		{tag: "no body", id: "missing", status: http.StatusNotFound, exp: "CORE API returned 404 Not Found"},
+	}
+	for _, td := range testData {
+		_, err := c.GetWork(td.id)
+		require.NotNil(t, err, td.tag)
+		apiErr, ok := err.(*APIError)
+		require.True(t, ok, td.tag)
+		assert.Equal(t, td.status, apiErr.StatusCode, td.tag)
+		assert.Equal(t, td.exp, err.Error(), td.tag)
+	}
+
+	_, err := c.GetWork("invalid")
+	require.NotNil(t, err)
+	_, ok := err.(*APIError)
+	assert.False(t, ok, "a decoding failure is not an API error")
+}
+
+//
+// Test Data
+//
+
+var testClientResponses = map[string]string{
+	"/v3/works/42": `{
+		"id": 42,
+		"title": "Open Access and Research Discovery",
+		"abstract": "An anonymized abstract.",
+		"authors": [{"name": "Davies, Nathan"}, {"name": "Smith, John"}],
+		"doi": "10.1234/example.42",
+		"identifiers": [{"identifier": "10.1234/example.42", "type": "DOI"}, {"identifier": "42", "type": "CORE_ID"}],
+		"documentType": "research",
+		"language": {"code": "en", "name": "English"},
+		"journals": [{"title": "Journal of Examples", "identifiers": ["1234-5679"]}],
+		"dataProviders": [{"id": 3, "name": "Open Research Online", "url": "https://api.core.ac.uk/v3/data-providers/3"}],
+		"outputs": ["https://api.core.ac.uk/v3/outputs/7"],
+		"downloadUrl": "https://core.ac.uk/download/7.pdf",
+		"yearPublished": 2019,
+		"unknownField": "ignored"
+	}`,
+	"/v3/outputs/7": `{"id": 7, "title": "Open Access and Research Discovery", "dataProvider": {"id": 3, "name": "Open Research Online"}}`,
+	"/v3/data-providers/3": `{
+		"id": 3,
+		"openDoarId": 123,
+		"name": "Open Research Online",
+		"oaiPmhUrl": "http://oro.open.ac.uk/cgi/oai2",
+		"location": {"countryCode": "GB", "latitude": 52.0, "longitude": -0.7},
+		"enabled": true
+	}`,
+	"/v3/journals/issn:0028-0836": `{"title": "Nature", "identifiers": ["0028-0836", "1476-4687"], "publisher": "Nature Publishing Group"}`,
+}
+
+var expWork = &Work{
+	ID:           42,
+	Title:        "Open Access and Research Discovery",
+	Abstract:     "An anonymized abstract.",
+	Authors:      []Author{{Name: "Davies, Nathan"}, {Name: "Smith, John"}},
+	DOI:          "10.1234/example.42",
+	Identifiers:  []Identifier{{Identifier: "10.1234/example.42", Type: "DOI"}, {Identifier: "42", Type: "CORE_ID"}},
+	DocumentType: "research",
+	Language:     Language{Code: "en", Name: "English"},
+	Journals:     []JournalRef{{Title: "Journal of Examples", Identifiers: []string{"1234-5679"}}},
+	DataProviders: []DataProviderRef{
+		{ID: 3, Name: "Open Research Online", URL: "https://api.core.ac.uk/v3/data-providers/3"},
+	},
+	Outputs:       []string{"https://api.core.ac.uk/v3/outputs/7"},
+	DownloadURL:   "https://core.ac.uk/download/7.pdf",
+	YearPublished: 2019,
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// DefaultAPIKeyEnv is the environment variable conventionally holding the CORE API key
+const DefaultAPIKeyEnv = "CORE_API_KEY"
+
+// Redacted replaces the API key wherever it would otherwise appear in an error
+const Redacted = "REDACTED"
+
+// ErrNoAPIKey is returned when no API key is given and none can be found from the configured Credentials
+var ErrNoAPIKey = errors.New("no CORE API key available")
+
+// Credentials supply the CORE API key. They are consulted for each request so that a rotated key is picked up.
+type Credentials interface {
+	APIKey() (string, error)
+}
+
+// EnvCredentials reads the API key from the named environment variable
+type EnvCredentials string
+
+// APIKey implements Credentials
+func (e EnvCredentials) APIKey() (string, error) {
+	key := strings.TrimSpace(os.Getenv(string(e)))
+	if key == "" {
+		return "", fmt.Errorf("environment variable %s is not set: %v", string(e), ErrNoAPIKey)
+	}
+	return key, nil
+}
+
+// FileCredentials reads the API key from the named file, such as a mounted secret. Surrounding whitespace is ignored.
+type FileCredentials string
+
+// APIKey implements Credentials
+func (f FileCredentials) APIKey() (string, error) {
+	data, err := ioutil.ReadFile(string(f))
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key file: %v", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("API key file %q is empty: %v", string(f), ErrNoAPIKey)
+	}
+	return key, nil
+}
+
+// CredentialsFunc adapts a function, such as a lookup in a secrets manager, to Credentials
+type CredentialsFunc func() (string, error)
+
+// APIKey implements Credentials
+func (cf CredentialsFunc) APIKey() (string, error) {
+	return cf()
+}
+
+// resolveAPIKey uses the key given if there is one, falling back to the credentials
+func resolveAPIKey(key string, creds Credentials) (string, error) {
+	if key != "" {
+		return key, nil
+	}
+	if creds == nil {
+		return "", ErrNoAPIKey
+	}
+	return creds.APIKey()
+}
+
+// redactedError replaces an error whose message held the API key. It deliberately does not unwrap to the original
+// error, which still holds the key.
+type redactedError struct {
+	msg string
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+// redact removes the key, in its raw and URL encoded forms, from the message of err. The error is returned unchanged
+// if it does not contain the key, so sentinel errors can still be compared. A *url.Error is rebuilt with the key
+// removed from its URL and underlying error, so it can still be inspected for a timeout or the like.
+func redact(err error, key string) error {
+	if err == nil || key == "" {
+		return err
+	}
+	msg := redactString(err.Error(), key)
+	if msg == err.Error() {
+		return err
+	}
+	if ue, ok := err.(*url.Error); ok {
+		return &url.Error{Op: ue.Op, URL: redactString(ue.URL, key), Err: redact(ue.Err, key)}
+	}
+	return &redactedError{msg: msg}
+}
+
+func redactString(s, key string) string {
+	for _, form := range []string{key, url.QueryEscape(key), url.PathEscape(key)} {
+		s = strings.Replace(s, form, Redacted, -1)
+	}
+	return s
+}
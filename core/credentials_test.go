@@ -0,0 +1,181 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nathj07/go-resourcesync/fetcher"
+)
+
+func TestCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	keyFile := filepath.Join(dir, "key")
+	require.Nil(t, ioutil.WriteFile(keyFile, []byte("file_key\n"), 0600))
+	emptyFile := filepath.Join(dir, "empty")
+	require.Nil(t, ioutil.WriteFile(emptyFile, []byte("\n"), 0600))
+	os.Setenv("TEST_CORE_API_KEY", "env_key")
+	defer os.Unsetenv("TEST_CORE_API_KEY")
+
+	testData := []struct {
+		tag    string
+		creds  Credentials
+		expKey string
+		expErr bool
+	}{
+		{tag: "env", creds: EnvCredentials("TEST_CORE_API_KEY"), expKey: "env_key"},
+		{tag: "env unset", creds: EnvCredentials("TEST_CORE_API_KEY_UNSET"), expErr: true},
+		{tag: "file", creds: FileCredentials(keyFile), expKey: "file_key"},
+		{tag: "file empty", creds: FileCredentials(emptyFile), expErr: true},
+		{tag: "file missing", creds: FileCredentials(filepath.Join(dir, "missing")), expErr: true},
+		{tag: "func", creds: CredentialsFunc(func() (string, error) { return "func_key", nil }), expKey: "func_key"},
+	}
+	for _, td := range testData {
+		key, err := td.creds.APIKey()
+		assert.Equal(t, td.expErr, err != nil, td.tag)
+		assert.Equal(t, td.expKey, key, td.tag)
+	}
+
+	key, err := resolveAPIKey("given", EnvCredentials("TEST_CORE_API_KEY"))
+	assert.Nil(t, err)
+	assert.Equal(t, "given", key, "an explicit key takes precedence")
+	_, err = resolveAPIKey("", nil)
+	assert.Equal(t, ErrNoAPIKey, err)
+}
+
+func TestRedact(t *testing.T) {
+	key := "se/cret key+1"
+	testData := []struct {
+		tag string
+		err error
+		exp string
+	}{
+		{tag: "raw", err: errors.New("bad key se/cret key+1"), exp: "bad key REDACTED"},
+		{tag: "query escaped", err: errors.New("GET http://x/?apiKey=se%2Fcret+key%2B1 failed"),
+			exp: "GET http://x/?apiKey=REDACTED failed"},
+		{tag: "path escaped", err: errors.New("GET http://x/se%2Fcret%20key+1 failed"), exp: "GET http://x/REDACTED failed"},
+	}
+	for _, td := range testData {
+		err := redact(td.err, key)
+		assert.Equal(t, td.exp, err.Error(), td.tag)
+		assert.Nil(t, errors.Unwrap(err), td.tag+": the original error is not reachable")
+	}
+	clean := errors.New("nothing to hide")
+	assert.Equal(t, clean, redact(clean, key), "errors without the key are unchanged")
+	assert.Nil(t, redact(nil, key))
+}
+
+func TestRedactURLError(t *testing.T) {
+	key := "top_secret"
+	orig := &url.Error{
+		Op:  "Get",
+		URL: "http://x/?apiKey=" + key,
+		Err: fmt.Errorf("dial tcp: lookup x with apiKey=%s: no such host", key),
+	}
+	err := redact(orig, key)
+	assert.Equal(t, `Get "http://x/?apiKey=REDACTED": dial tcp: lookup x with apiKey=REDACTED: no such host`, err.Error())
+
+	var ue *url.Error
+	require.True(t, errors.As(err, &ue))
+	assert.Equal(t, "Get", ue.Op)
+	assert.Equal(t, "http://x/?apiKey="+Redacted, ue.URL)
+	for e := error(ue); e != nil; e = errors.Unwrap(e) {
+		assert.False(t, strings.Contains(e.Error(), key), e.Error())
+	}
+}
+
+func TestProcessAPIKey(t *testing.T) {
+	var gotQuery, gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		gotHeader = r.Header.Get("apiKey")
+		fmt.Fprint(w, string(testArticleData))
+	}))
+	defer server.Close()
+
+	testData := []struct {
+		tag       string
+		ce        *Extractor
+		target    string
+		apiKey    string
+		expQuery  string
+		expHeader string
+	}{
+		{tag: "query", ce: &Extractor{Fetcher: &fetcher.BasicRSFetcher{}}, target: server.URL + "/articles/get/1",
+			apiKey: "a&b=c", expQuery: "apiKey=a%26b%3Dc"},
+		{tag: "existing query", ce: &Extractor{Fetcher: &fetcher.BasicRSFetcher{}}, target: server.URL + "/articles/get/1?urls=true",
+			apiKey: "key", expQuery: "apiKey=key&urls=true"},
+		{tag: "header", ce: &Extractor{Fetcher: &fetcher.BasicRSFetcher{}, KeyHeader: "apiKey"},
+			target: server.URL + "/articles/get/1?urls=true", apiKey: "key", expQuery: "urls=true", expHeader: "key"},
+		{tag: "header unsupported", ce: &Extractor{Fetcher: plainFetcher{}, KeyHeader: "apiKey"},
+			target: server.URL + "/articles/get/1", apiKey: "key", expQuery: "apiKey=key"},
+		{tag: "credentials", ce: &Extractor{Fetcher: &fetcher.BasicRSFetcher{},
+			Credentials: CredentialsFunc(func() (string, error) { return "stored", nil })},
+			target: server.URL + "/articles/get/1", expQuery: "apiKey=stored"},
+	}
+	for _, td := range testData {
+		gotQuery, gotHeader = "", ""
+		article, err := td.ce.Process(td.target, td.apiKey)
+		require.Nil(t, err, td.tag)
+		assert.Equal(t, expArticleWrapper, article, td.tag)
+		assert.Equal(t, td.expQuery, gotQuery, td.tag)
+		assert.Equal(t, td.expHeader, gotHeader, td.tag)
+	}
+
+	_, err := (&Extractor{Fetcher: &fetcher.BasicRSFetcher{}}).Process(server.URL, "")
+	assert.Equal(t, ErrNoAPIKey, err)
+}
+
+func TestProcessRedactsKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	target := server.URL
+	server.Close() // so the request fails with an error quoting the URL
+
+	ce := &Extractor{Fetcher: &fetcher.BasicRSFetcher{}}
+	_, err := ce.Process(target, "top_secret")
+	require.NotNil(t, err)
+	assert.False(t, strings.Contains(err.Error(), "top_secret"), err.Error())
+	assert.True(t, strings.Contains(err.Error(), "apiKey="+Redacted), err.Error())
+}
+
+func TestClientCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer stored" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"id": 1}`)
+	}))
+	defer server.Close()
+	c := &Client{BaseURL: server.URL, Credentials: CredentialsFunc(func() (string, error) { return "stored", nil })}
+	work, err := c.GetWork("1")
+	require.Nil(t, err)
+	assert.Equal(t, int64(1), work.ID)
+
+	c.Credentials = CredentialsFunc(func() (string, error) { return "", ErrNoAPIKey })
+	_, err = c.GetWork("1")
+	assert.Equal(t, ErrNoAPIKey, err)
+}
+
+//
+// Test Data
+//
+
+// plainFetcher cannot send headers
+type plainFetcher struct{}
+
+func (plainFetcher) Fetch(source string) ([]byte, int, error) {
+	return (&fetcher.BasicRSFetcher{}).Fetch(source)
+}
@@ -0,0 +1,370 @@
+package core
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// How an article was found to be a duplicate, as given in its Provenance
+const (
+	MatchPDFHash = "pdfHash"
+	MatchDOI     = "doi"
+	MatchMAGID   = "magId"
+	MatchTitle   = "title" // a near identical title and overlapping authors
+)
+
+// Defaults for the fuzzy matching of a Deduplicator
+const (
+	DefaultTitleSimilarity  = 0.9
+	DefaultAuthorSimilarity = 0.5
+)
+
+// minFuzzyTitleTokens is the fewest words a title needs to be matched fuzzily, shorter titles such as "Editorial" are
+// shared by too many unrelated articles
+const minFuzzyTitleTokens = 3
+
+// Deduplicator clusters the articles of a fast sync stream that are the same paper, harvested from several
+// repositories. Articles are first joined by an exact PDF hash, DOI or MAG ID. Articles are then also joined where
+// their titles, normalised to lower case words, are near identical and enough of their authors' family names are
+// shared, as long as their DOIs do not differ and their years are no more than one apart. Joins are transitive.
+//
+// Articles are added one at a time with Add, after which Clusters gives a canonical record for each paper. The
+// records of every article are held until then, so skipping HeavyFSArticleFields when reading is recommended.
+type Deduplicator struct {
+	TitleSimilarity  float64 // from 0 to 1, the similarity of normalised titles needed to match; DefaultTitleSimilarity if 0
+	AuthorSimilarity float64 // from 0 to 1, the share of the shorter author list found in the other; DefaultAuthorSimilarity if 0
+
+	records []*Record
+	matches []Provenance
+	parent  []int
+	exact   map[string]int   // exact key to the first article with it
+	blocks  map[string][]int // title block key to the articles with it
+	titles  [][]rune
+	authors []map[string]bool
+}
+
+// Cluster is a paper found in the stream, with the articles it was found in
+type Cluster struct {
+	Canonical *Record      `json:"canonical"` // the most complete of the articles, with missing fields filled from the others
+	Sources   []Provenance `json:"sources"`
+}
+
+// Provenance records an article of a cluster and how it was joined to the cluster
+type Provenance struct {
+	CoreID      string `json:"coreId"`
+	OAI         string `json:"oai,omitempty"`
+	MatchedBy   string `json:"matchedBy,omitempty"`   // one of the Match constants, empty for the first article of the cluster
+	MatchedWith string `json:"matchedWith,omitempty"` // the CoreID of the article matched
+}
+
+// NewDeduplicator returns a Deduplicator with the default similarities, the zero value is equally ready to use
+func NewDeduplicator() *Deduplicator {
+	return &Deduplicator{}
+}
+
+// Add adds an article from the stream
+func (d *Deduplicator) Add(fs *FSArticle) {
+	if d.exact == nil {
+		d.exact = map[string]int{}
+		d.blocks = map[string][]int{}
+	}
+	r := fs.Record()
+	i := len(d.records)
+	d.records = append(d.records, r)
+	d.matches = append(d.matches, Provenance{CoreID: r.CoreID, OAI: r.OAI})
+	d.parent = append(d.parent, i)
+
+	for _, k := range []struct{ by, key string }{
+		{MatchPDFHash, strings.ToLower(strings.TrimSpace(r.PDFHash))},
+		{MatchDOI, r.DOI},
+		{MatchMAGID, strings.TrimSpace(r.MAGID)},
+	} {
+		if k.key == "" {
+			continue
+		}
+		key := k.by + ":" + k.key
+		if j, ok := d.exact[key]; ok {
+			d.join(i, j, k.by)
+		} else {
+			d.exact[key] = i
+		}
+	}
+
+	words := strings.Fields(normaliseTitle(r.Title))
+	d.titles = append(d.titles, []rune(strings.Join(words, " ")))
+	authors := map[string]bool{}
+	for _, a := range r.Authors {
+		if family := authorFamily(a); family != "" {
+			authors[family] = true
+		}
+	}
+	d.authors = append(d.authors, authors)
+	if len(words) < minFuzzyTitleTokens {
+		return
+	}
+	// titles are only compared within blocks sharing their first or last words, so that a difference at either end,
+	// such as a "Research Report:" prefix, still leaves a block in common
+	n := minFuzzyTitleTokens
+	for _, block := range []string{"^" + strings.Join(words[:n], " "), "$" + strings.Join(words[len(words)-n:], " ")} {
+		for _, j := range d.blocks[block] {
+			if d.find(i) != d.find(j) && d.similar(i, j) {
+				d.join(i, j, MatchTitle)
+			}
+		}
+		d.blocks[block] = append(d.blocks[block], i)
+	}
+}
+
+// Clusters returns a cluster for each paper added, in the order their first article was added, with the sources in
+// the order they were added
+func (d *Deduplicator) Clusters() []*Cluster {
+	members := map[int][]int{}
+	for i := range d.records {
+		root := d.find(i)
+		members[root] = append(members[root], i)
+	}
+	var res []*Cluster
+	for i := range d.records {
+		// the root of a cluster is always its first article
+		if d.find(i) != i {
+			continue
+		}
+		c := &Cluster{Canonical: d.canonical(members[i])}
+		for _, j := range members[i] {
+			c.Sources = append(c.Sources, d.matches[j])
+		}
+		res = append(res, c)
+	}
+	return res
+}
+
+// join puts article i, the one being added, into the cluster of article j
+func (d *Deduplicator) join(i, j int, by string) {
+	if d.matches[i].MatchedBy == "" {
+		d.matches[i].MatchedBy, d.matches[i].MatchedWith = by, d.records[j].CoreID
+	}
+	ri, rj := d.find(i), d.find(j)
+	if ri == rj {
+		return
+	}
+	// the earlier article stays the root, keeping clusters in the order they were first seen
+	if ri < rj {
+		d.parent[rj] = ri
+	} else {
+		d.parent[ri] = rj
+	}
+}
+
+func (d *Deduplicator) find(i int) int {
+	for d.parent[i] != i {
+		d.parent[i] = d.parent[d.parent[i]]
+		i = d.parent[i]
+	}
+	return i
+}
+
+// similar reports whether articles i and j look to be the same paper
+func (d *Deduplicator) similar(i, j int) bool {
+	ri, rj := d.records[i], d.records[j]
+	if ri.DOI != "" && rj.DOI != "" && ri.DOI != rj.DOI {
+		return false
+	}
+	if ri.Year > 0 && rj.Year > 0 && (ri.Year-rj.Year > 1 || rj.Year-ri.Year > 1) {
+		return false
+	}
+	titleSimilarity, authorSimilarity := d.TitleSimilarity, d.AuthorSimilarity
+	if titleSimilarity == 0 {
+		titleSimilarity = DefaultTitleSimilarity
+	}
+	if authorSimilarity == 0 {
+		authorSimilarity = DefaultAuthorSimilarity
+	}
+	ai, aj := d.authors[i], d.authors[j]
+	if len(ai) == 0 || len(aj) == 0 {
+		// with no authors to compare the titles must be the same
+		return string(d.titles[i]) == string(d.titles[j])
+	}
+	shared := 0
+	for a := range ai {
+		if aj[a] {
+			shared++
+		}
+	}
+	fewest := len(ai)
+	if len(aj) < fewest {
+		fewest = len(aj)
+	}
+	if float64(shared)/float64(fewest) < authorSimilarity {
+		return false
+	}
+	// the edit distance is at least the difference in length, which rules out most titles cheaply
+	ti, tj := len(d.titles[i]), len(d.titles[j])
+	if ti < tj {
+		ti, tj = tj, ti
+	}
+	if float64(tj)/float64(ti) < titleSimilarity {
+		return false
+	}
+	return similarity(d.titles[i], d.titles[j]) >= titleSimilarity
+}
+
+// canonical builds the record of a cluster from the most complete of its articles, filling fields it lacks from the
+// others in turn
+func (d *Deduplicator) canonical(members []int) *Record {
+	ranked := make([]*Record, len(members))
+	for k, i := range members {
+		ranked[k] = d.records[i]
+	}
+	sort.SliceStable(ranked, func(a, b int) bool {
+		return completeness(ranked[a]) > completeness(ranked[b])
+	})
+	res := *ranked[0]
+	for _, r := range ranked[1:] {
+		for _, f := range []struct {
+			dst *string
+			src string
+		}{
+			{&res.Title, r.Title},
+			{&res.Abstract, r.Abstract},
+			{&res.DOI, r.DOI},
+			{&res.OAI, r.OAI},
+			{&res.MAGID, r.MAGID},
+			{&res.DocumentType, r.DocumentType},
+			{&res.Publisher, r.Publisher},
+			{&res.DatePublished, r.DatePublished},
+			{&res.DownloadURL, r.DownloadURL},
+			{&res.FullText, r.FullText},
+			{&res.PDFHash, r.PDFHash},
+		} {
+			if *f.dst == "" {
+				*f.dst = f.src
+			}
+		}
+		if len(res.Authors) == 0 {
+			res.Authors = r.Authors
+		}
+		if len(res.Journals) == 0 {
+			res.Journals = r.Journals
+		}
+		if len(res.References) == 0 {
+			res.References = r.References
+		}
+		if res.Language.Code == "" {
+			res.Language = r.Language
+		}
+		if res.Year == 0 {
+			res.Year = r.Year
+		}
+		if r.CitationCount > res.CitationCount {
+			res.CitationCount = r.CitationCount
+		}
+		res.Identifiers = union(res.Identifiers, r.Identifiers)
+		res.URLs = union(res.URLs, r.URLs)
+	}
+	return &res
+}
+
+// completeness scores how much of the useful metadata a record holds, identifiers and full text counting most
+func completeness(r *Record) int {
+	score := 0
+	for _, f := range []struct {
+		present bool
+		weight  int
+	}{
+		{r.DOI != "", 4},
+		{r.DownloadURL != "" || r.FullText != "", 3},
+		{r.Abstract != "", 2},
+		{len(r.Authors) > 0, 2},
+		{r.Year > 0, 1},
+		{len(r.Journals) > 0, 1},
+		{r.Publisher != "", 1},
+		{len(r.References) > 0, 1},
+	} {
+		if f.present {
+			score += f.weight
+		}
+	}
+	return score
+}
+
+// union appends the values of b not already in a, without changing a
+func union(a, b []string) []string {
+	res := append([]string{}, a...)
+	seen := map[string]bool{}
+	for _, v := range a {
+		seen[v] = true
+	}
+	for _, v := range b {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// normaliseTitle lower cases the title and replaces everything other than letters and digits with spaces
+func normaliseTitle(title string) string {
+	return strings.Join(strings.Fields(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, title)), " ")
+}
+
+// authorFamily returns the normalised family name of an author given as "Family, Given" or "Given Family"
+func authorFamily(name string) string {
+	family, _, ok := splitName(name)
+	if !ok {
+		words := strings.Fields(name)
+		if len(words) == 0 {
+			return ""
+		}
+		family = words[len(words)-1]
+	}
+	return normaliseTitle(family)
+}
+
+// similarity is 1 less the edit distance between a and b as a share of the longer, 1 for identical strings
+func similarity(a, b []rune) float64 {
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeduplicator(t *testing.T) {
+	testData := []struct {
+		tag      string
+		articles []*FSArticle
+		exp      [][]Provenance // the sources of each cluster
+	}{
+		{
+			tag: "pdf hash",
+			articles: []*FSArticle{
+				{CoreID: "1", PDFHashValue: "97a86466f1af"},
+				{CoreID: "2", PDFHashValue: "97A86466F1AF"},
+			},
+			exp: [][]Provenance{{{CoreID: "1"}, {CoreID: "2", MatchedBy: MatchPDFHash, MatchedWith: "1"}}},
+		},
+		{
+			tag: "doi in different forms",
+			articles: []*FSArticle{
+				{CoreID: "1", DOI: "10.1056/ABC"},
+				{CoreID: "2", Identifiers: []string{"oai:x.org:2", "https://doi.org/10.1056/abc"}, OAIID: "oai:x.org:2"},
+			},
+			exp: [][]Provenance{{{CoreID: "1"}, {CoreID: "2", OAI: "oai:x.org:2", MatchedBy: MatchDOI, MatchedWith: "1"}}},
+		},
+		{
+			tag: "mag id",
+			articles: []*FSArticle{
+				{CoreID: "1", MAGID: "2144009174"},
+				{CoreID: "2", MAGID: "2144009175"},
+				{CoreID: "3", MAGID: "2144009174"},
+			},
+			exp: [][]Provenance{
+				{{CoreID: "1"}, {CoreID: "3", MatchedBy: MatchMAGID, MatchedWith: "1"}},
+				{{CoreID: "2"}},
+			},
+		},
+		{
+			tag: "transitive",
+			articles: []*FSArticle{
+				{CoreID: "1", PDFHashValue: "aa"},
+				{CoreID: "2", DOI: "10.1056/x"},
+				{CoreID: "3", PDFHashValue: "aa", DOI: "10.1056/x"},
+			},
+			exp: [][]Provenance{{{CoreID: "1"}, {CoreID: "2"}, {CoreID: "3", MatchedBy: MatchPDFHash, MatchedWith: "1"}}},
+		},
+		{
+			tag: "near identical title",
+			articles: []*FSArticle{
+				{CoreID: "1", Title: "Disadvantaged Teenagers, Intimate Partner Violence and Coercive Control", Year: 2010,
+					Authors: []string{"Wood, Marsha", "Barter, Christine", "Berridge, David"}},
+				{CoreID: "2", Title: "Disadvantaged teenagers: intimate partner violence & coercive control.", Year: 2011,
+					Authors: []string{"Marsha Wood", "C. Barter"}},
+			},
+			exp: [][]Provenance{{{CoreID: "1"}, {CoreID: "2", MatchedBy: MatchTitle, MatchedWith: "1"}}},
+		},
+		{
+			tag: "same title without authors",
+			articles: []*FSArticle{
+				{CoreID: "1", Title: "A Study of Coercive Control"},
+				{CoreID: "2", Title: "A study of coercive control!"},
+				{CoreID: "3", Title: "A study of coercive controls"},
+			},
+			exp: [][]Provenance{{{CoreID: "1"}, {CoreID: "2", MatchedBy: MatchTitle, MatchedWith: "1"}}, {{CoreID: "3"}}},
+		},
+		{
+			tag: "not duplicates",
+			articles: []*FSArticle{
+				{CoreID: "1", Title: "Intimate Partner Violence and Coercive Control", Year: 2010, DOI: "10.1056/a",
+					Authors: []string{"Wood, Marsha"}},
+				{CoreID: "2", Title: "Intimate Partner Violence and Coercive Control", Year: 2010,
+					Authors: []string{"Smith, John"}},
+				{CoreID: "3", Title: "Intimate Partner Violence and Coercive Control", Year: 2015,
+					Authors: []string{"Wood, Marsha"}},
+				{CoreID: "4", Title: "Intimate Partner Violence and Coercive Control", Year: 2010, DOI: "10.1056/b",
+					Authors: []string{"Wood, Marsha"}},
+				{CoreID: "5", Title: "Intimate Partner Violence and Financial Control", Year: 2010,
+					Authors: []string{"Wood, Marsha"}},
+				{CoreID: "6", Title: "Editorial", Authors: []string{"Wood, Marsha"}},
+				{CoreID: "7", Title: "Editorial", Authors: []string{"Wood, Marsha"}},
+			},
+			exp: [][]Provenance{{{CoreID: "1"}}, {{CoreID: "2"}}, {{CoreID: "3"}}, {{CoreID: "4"}}, {{CoreID: "5"}},
+				{{CoreID: "6"}}, {{CoreID: "7"}}},
+		},
+	}
+	for _, td := range testData {
+		d := NewDeduplicator()
+		for _, a := range td.articles {
+			d.Add(a)
+		}
+		var sources [][]Provenance
+		for _, c := range d.Clusters() {
+			sources = append(sources, c.Sources)
+		}
+		assert.Equal(t, td.exp, sources, td.tag)
+	}
+}
+
+func TestDeduplicatorSimilarity(t *testing.T) {
+	a := &FSArticle{CoreID: "1", Title: "Intimate Partner Violence and Coercive Control", Authors: []string{"Wood, Marsha"}}
+	b := &FSArticle{CoreID: "2", Title: "Intimate Partner Violence and Coercive Controls", Authors: []string{"Wood, Marsha", "Barter, Christine"}}
+
+	d := NewDeduplicator()
+	d.Add(a)
+	d.Add(b)
+	assert.Len(t, d.Clusters(), 1)
+
+	d = NewDeduplicator()
+	d.TitleSimilarity = 1
+	d.Add(a)
+	d.Add(b)
+	assert.Len(t, d.Clusters(), 2, "titles must be identical")
+
+	d = NewDeduplicator()
+	d.AuthorSimilarity = 1
+	d.Add(b)
+	d.Add(&FSArticle{CoreID: "3", Title: b.Title, Authors: []string{"Wood, M.", "Smith, John"}})
+	assert.Len(t, d.Clusters(), 2, "all authors of the shorter list must be shared")
+}
+
+func TestDeduplicatorZeroValue(t *testing.T) {
+	d := &Deduplicator{TitleSimilarity: 0.8}
+	d.Add(&FSArticle{CoreID: "1", DOI: "10.1056/a"})
+	d.Add(&FSArticle{CoreID: "2", DOI: "10.1056/A"})
+	clusters := d.Clusters()
+	require.Len(t, clusters, 1)
+	assert.Equal(t, []Provenance{{CoreID: "1"}, {CoreID: "2", MatchedBy: MatchDOI, MatchedWith: "1"}}, clusters[0].Sources)
+	assert.Empty(t, (&Deduplicator{}).Clusters())
+}
+
+func TestDeduplicatorCanonical(t *testing.T) {
+	d := NewDeduplicator()
+	d.Add(&FSArticle{CoreID: "1", PDFHashValue: "aa", Title: "T", Identifiers: []string{"oai:x.org:1"}, Year: 2010,
+		URLs: []string{"http://x.org/1"}, Enrichments: FSArticleEnrichment{CitationCount: 5}})
+	d.Add(&FSArticle{CoreID: "2", PDFHashValue: "aa", Title: "The Title", DOI: "10.1056/a", Abstract: "About it",
+		Authors: []string{"Wood, Marsha"}, DownloadURL: "http://y.org/2.pdf", Identifiers: []string{"oai:y.org:2"},
+		Enrichments: FSArticleEnrichment{CitationCount: 3}})
+	clusters := d.Clusters()
+	require.Len(t, clusters, 1)
+	exp := &Record{
+		Source:        SourceFastSync,
+		CoreID:        "2",
+		Title:         "The Title",
+		Abstract:      "About it",
+		Authors:       []string{"Wood, Marsha"},
+		DOI:           "10.1056/a",
+		Identifiers:   []string{"oai:y.org:2", "oai:x.org:1"},
+		Year:          2010,
+		DownloadURL:   "http://y.org/2.pdf",
+		URLs:          []string{"http://x.org/1"},
+		PDFHash:       "aa",
+		CitationCount: 5,
+	}
+	assert.Equal(t, exp, clusters[0].Canonical)
+	assert.Equal(t, []Provenance{{CoreID: "1"}, {CoreID: "2", MatchedBy: MatchPDFHash, MatchedWith: "1"}}, clusters[0].Sources)
+}
+
+func TestSimilarity(t *testing.T) {
+	testData := []struct {
+		tag  string
+		a, b string
+		exp  float64
+	}{
+		{tag: "empty", a: "", b: "", exp: 1},
+		{tag: "identical", a: "abc", b: "abc", exp: 1},
+		{tag: "substitution", a: "abcd", b: "abed", exp: 0.75},
+		{tag: "mixed edits", a: "kitten", b: "sitting", exp: 1 - 3.0/7},
+		{tag: "one empty", a: "abc", b: "", exp: 0},
+	}
+	for _, td := range testData {
+		assert.InDelta(t, td.exp, similarity([]rune(td.a), []rune(td.b)), 1e-9, td.tag)
+	}
+}
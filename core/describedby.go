@@ -0,0 +1,127 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/nathj07/go-resourcesync/fetcher"
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+// RelDescribedBy is the rs:ln relation linking a resource to its metadata
+const RelDescribedBy = "describedby"
+
+// ErrNoDescribedBy is returned when a resource has no describedby link
+var ErrNoDescribedBy = errors.New("resource has no describedby link")
+
+// Decoder turns the raw metadata fetched from a describedby link into a Go value
+type Decoder func(rawData []byte) (interface{}, error)
+
+// DescribedResource pairs the location of some content with its decoded metadata
+type DescribedResource struct {
+	ContentURL   string
+	MetadataURL  string
+	MetadataType string // the media type the metadata was decoded as, empty if it could not be determined
+	Resource     resourcesync.ResourceURL
+	Metadata     interface{} // *ArticleWrapper unless a Decoder registered for MetadataType returns something else
+}
+
+// Describer follows the describedby links found on the entries of CORE resource lists, fetching the metadata and
+// decoding it. Metadata is decoded with the Decoder registered for its media type, taken from the type of the link
+// or failing that the extension of its URL; anything else is decoded with Extractor.ExtractArticle.
+type Describer struct {
+	Fetcher   fetcher.RSFetcher
+	Extractor *Extractor
+	decoders  map[string]Decoder
+}
+
+// NewDescriber is the simplest way to instantiate a ready to use Describer, the one fetcher is used throughout
+func NewDescriber(f fetcher.RSFetcher) *Describer {
+	return &Describer{
+		Fetcher: f,
+		Extractor: &Extractor{
+			Fetcher: f,
+		},
+	}
+}
+
+// RegisterDecoder sets the Decoder used for metadata of the given media type, replacing any already registered
+func (d *Describer) RegisterDecoder(mediaType string, dec Decoder) {
+	if d.decoders == nil {
+		d.decoders = map[string]Decoder{}
+	}
+	d.decoders[normaliseMediaType(mediaType)] = dec
+}
+
+// Describe fetches and decodes the metadata the resource links to by its first describedby link, whatever other links
+// it has. ErrNoDescribedBy is returned if there is no such link.
+func (d *Describer) Describe(ru resourcesync.ResourceURL) (*DescribedResource, error) {
+	ln, ok := ru.Link(RelDescribedBy)
+	if !ok || strings.TrimSpace(ln.Href) == "" {
+		return nil, ErrNoDescribedBy
+	}
+	dr := &DescribedResource{
+		ContentURL:  strings.TrimSpace(ru.Loc),
+		MetadataURL: strings.TrimSpace(ln.Href),
+		Resource:    ru,
+	}
+	dr.MetadataType = metadataType(ln)
+	data, status, err := d.Fetcher.Fetch(dr.MetadataURL)
+	if err != nil {
+		return nil, fmt.Errorf("%d: failed to fetch metadata %q: %v", status, dr.MetadataURL, err)
+	}
+	dec, ok := d.decoders[dr.MetadataType]
+	if !ok {
+		dec = d.extractArticle
+	}
+	if dr.Metadata, err = dec(data); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata %q: %v", dr.MetadataURL, err)
+	}
+	return dr, nil
+}
+
+// Walk visits every entry of the resource list, or index, at target matching the filters and calls fn with each
+// resource paired with its metadata. Failures to describe a single resource are passed to fn along with the
+// resource, leaving the caller to decide whether to carry on; if fn returns an error the walk stops.
+func (d *Describer) Walk(rs *resourcesync.ResourceSync, target string, fn func(dr *DescribedResource, err error) error,
+	filters ...resourcesync.Filter) error {
+	return rs.Walk(target, func(ru resourcesync.ResourceURL) error {
+		dr, err := d.Describe(ru)
+		if err != nil {
+			return fn(&DescribedResource{ContentURL: strings.TrimSpace(ru.Loc), Resource: ru}, err)
+		}
+		return fn(dr, nil)
+	}, filters...)
+}
+
+func (d *Describer) extractArticle(rawData []byte) (interface{}, error) {
+	ce := d.Extractor
+	if ce == nil {
+		ce = &Extractor{}
+	}
+	return ce.ExtractArticle(rawData)
+}
+
+// metadataType works out the media type of the linked metadata
+func metadataType(ln resourcesync.RSLN) string {
+	if ln.Type != "" {
+		return normaliseMediaType(ln.Type)
+	}
+	u, err := url.Parse(strings.TrimSpace(ln.Href))
+	if err != nil {
+		return ""
+	}
+	return normaliseMediaType(mime.TypeByExtension(path.Ext(u.Path)))
+}
+
+// normaliseMediaType drops any parameters, such as charset, from a media type
+func normaliseMediaType(t string) string {
+	if mt, _, err := mime.ParseMediaType(t); err == nil {
+		return mt
+	}
+	return strings.ToLower(strings.TrimSpace(t))
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nathj07/go-resourcesync/fetcher"
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+func TestDescribe(t *testing.T) {
+	server := describedByServer()
+	defer server.Close()
+	d := NewDescriber(&fetcher.BasicRSFetcher{})
+
+	dr, err := d.Describe(resourcesync.ResourceURL{
+		Loc:  "http://example.com/1.pdf",
+		RSLN: resourcesync.RSLN{Rel: "describedBy", Href: server.URL + "/1.json"},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, "http://example.com/1.pdf", dr.ContentURL)
+	assert.Equal(t, server.URL+"/1.json", dr.MetadataURL)
+	assert.Equal(t, "application/json", dr.MetadataType)
+	assert.Equal(t, expArticleWrapper, dr.Metadata)
+
+	_, err = d.Describe(resourcesync.ResourceURL{Loc: "http://example.com/1.pdf"})
+	assert.Equal(t, ErrNoDescribedBy, err)
+
+	dr, err = d.Describe(resourcesync.ResourceURL{
+		Loc:   "http://example.com/1.pdf",
+		RSLN:  resourcesync.RSLN{Rel: "duplicate", Href: "http://mirror.example.com/1.pdf"},
+		Links: []resourcesync.RSLN{{Rel: "DESCRIBEDBY", Href: server.URL + "/1.json"}},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, server.URL+"/1.json", dr.MetadataURL, "the describedby link is used whatever its position")
+
+	_, err = d.Describe(resourcesync.ResourceURL{
+		Loc:  "http://example.com/1.pdf",
+		RSLN: resourcesync.RSLN{Rel: "duplicate", Href: "http://mirror.example.com/1.pdf"},
+	})
+	assert.Equal(t, ErrNoDescribedBy, err)
+
+	_, err = d.Describe(resourcesync.ResourceURL{
+		Loc:  "http://example.com/1.pdf",
+		RSLN: resourcesync.RSLN{Rel: "describedby", Href: server.URL + "/missing.json"},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestDescriberWalk(t *testing.T) {
+	server := describedByServer()
+	defer server.Close()
+	d := NewDescriber(&fetcher.BasicRSFetcher{})
+	d.RegisterDecoder("application/xml; charset=utf-8", func(rawData []byte) (interface{}, error) {
+		return strings.ToUpper(string(rawData)), nil
+	})
+
+	type result struct {
+		content  string
+		metadata interface{}
+		err      error
+	}
+	var got []result
+	err := d.Walk(resourcesync.New(&fetcher.BasicRSFetcher{}), server.URL+"/resourcelist.xml", func(dr *DescribedResource, err error) error {
+		got = append(got, result{content: dr.ContentURL, metadata: dr.Metadata, err: err})
+		return nil
+	})
+	require.Nil(t, err)
+	require.Len(t, got, 3)
+	assert.Equal(t, result{content: "http://example.com/1.pdf", metadata: expArticleWrapper}, got[0])
+	assert.Equal(t, result{content: "http://example.com/2.pdf", metadata: "<RECORD/>"}, got[1])
+	assert.Equal(t, result{content: "http://example.com/3.pdf", err: ErrNoDescribedBy}, got[2])
+}
+
+// describedByServer serves a resource list whose entries link to JSON and XML metadata
+func describedByServer() *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	mux.HandleFunc("/resourcelist.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:md capability="resourcelist"/>
+	<url><loc>http://example.com/1.pdf</loc><rs:ln rel="describedBy" href="%[1]s/1.json"/></url>
+	<url><loc>http://example.com/2.pdf</loc><rs:ln rel="duplicate" href="http://mirror.example.com/2.pdf"/><rs:ln rel="DescribedBy" href="%[1]s/2" type="application/xml"/></url>
+	<url><loc>http://example.com/3.pdf</loc></url>
+</urlset>`, server.URL)
+	})
+	mux.HandleFunc("/1.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testArticleData)
+	})
+	mux.HandleFunc("/2", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<record/>")
+	})
+	return server
+}
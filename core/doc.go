@@ -2,5 +2,37 @@
 // The main public functions allow you to either send in a URL ad apiKey - Process(). Or send in
 // []byte from an article page JSON - ExtractArticle(). In both cases you get a ArticleWrapper object back with the
 // extracted data available for further inspection and use.
+//
+// A Describer follows the describedby links of the entries in CORE resource lists, pairing each content URL with
+// its decoded metadata.
+//
+// A Client makes requests to the CORE API v3, authenticating with a Bearer token, and decodes works, outputs, data
+// providers and journals into the v3 models. SearchWorks returns a WorkIterator that requests further pages, by
+// offset or scroll ID, as the results are read. The Client honours the rate limit headers CORE returns, waiting
+// before further requests once the allowance is used up. GetArticles fetches many works by ID, in batches made
+// concurrently, giving a result or error for each ID.
+//
+// The API key can be given directly or supplied by Credentials, read from an environment variable, a file or any
+// other source. It is redacted from every error returned.
+//
+// An FSArticleReader decodes the articles of a fast sync file one at a time, from newline delimited JSON or a JSON
+// array, reporting where any malformed record is and optionally skipping them. Heavy fields such as the full text can
+// be skipped without ever being held in memory.
+//
+// Articles from the v2 API, Works from v3 and FSArticles from fast sync each convert to a Record, a single normalised
+// form whichever channel the data came from. Any of them can be exported to BibTeX, RIS, CSL-JSON and Dublin Core XML,
+// for use in reference managers.
+//
+// NormaliseDOI and NormaliseISSN validate DOIs and ISSNs, including the ISSN check digit, and give them in a single
+// form for matching. ClassifyIdentifier works out the kind of an untyped identifier, and CheckIdentifiers sorts all
+// the identifiers of an FSArticle by kind, reporting those that are invalid. The DOI of a Record is normalised where
+// it can be, and otherwise kept lowercased.
+//
+// A Deduplicator clusters the FSArticles of a stream that are the same paper, harvested from several repositories,
+// matching identifiers exactly and then titles and authors fuzzily. Each cluster has a canonical Record and the
+// provenance of its articles.
+//
+// A CitationGraph resolves the enrichment references of a stream of FSArticles to the articles they cite, by CORE ID,
+// DOI or title, and writes the resulting graph as GraphML or an edge list CSV.
 
 package core
@@ -0,0 +1,328 @@
+package core
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Citable is implemented by the CORE article types, FSArticle, Article, Work and the normalised Record, so that they
+// can be exported to the bibliographic formats reference managers import
+type Citable interface {
+	citation() citation
+}
+
+// citation holds the bibliographic details shared by the export formats
+type citation struct {
+	ID        string
+	Thesis    bool
+	Title     string
+	Authors   []string // as CORE gives them, normally "Family, Given"
+	Year      int
+	Journal   string
+	ISSN      string
+	Publisher string
+	DOI       string
+	URL       string
+	Language  string
+}
+
+// kind chooses between the broad types of work the export formats distinguish
+func (c citation) kind() string {
+	switch {
+	case c.Thesis:
+		return "thesis"
+	case c.Journal != "" || c.ISSN != "":
+		return "article"
+	default:
+		return "misc"
+	}
+}
+
+func (r *Record) citation() citation {
+	c := citation{
+		ID:        r.CoreID,
+		Thesis:    r.DocumentType == "thesis",
+		Title:     r.Title,
+		Authors:   r.Authors,
+		Year:      r.Year,
+		Publisher: r.Publisher,
+		DOI:       r.DOI,
+		URL:       r.DownloadURL,
+		Language:  r.Language.Code,
+	}
+	for _, j := range r.Journals {
+		if c.Journal == "" {
+			c.Journal = j.Title
+		}
+		for _, id := range j.Identifiers {
+			if c.ISSN == "" {
+				c.ISSN = issnFrom(id)
+			}
+		}
+	}
+	return c
+}
+
+func (fs *FSArticle) citation() citation {
+	return fs.Record().citation()
+}
+
+func (a *Article) citation() citation {
+	return a.Record().citation()
+}
+
+func (w *Work) citation() citation {
+	return w.Record().citation()
+}
+
+// splitName splits a name given as "Family, Given"; ok is false if the name is not in that form
+func splitName(name string) (family, given string, ok bool) {
+	parts := strings.SplitN(name, ",", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true
+}
+
+// ExportBibTeX writes the items as BibTeX entries, keyed "core" followed by the CORE ID
+func ExportBibTeX(w io.Writer, items ...Citable) error {
+	for i, item := range items {
+		c := item.citation()
+		entryType := map[string]string{"thesis": "phdthesis", "article": "article", "misc": "misc"}[c.kind()]
+		sb := &strings.Builder{}
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(sb, "@%s{core%s", entryType, bibtexKey(c.ID))
+		for _, f := range []struct{ name, value string }{
+			{"title", c.Title},
+			{"author", bibtexAuthors(c.Authors)},
+			{"year", yearString(c.Year)},
+			{"journal", c.Journal},
+			{"issn", c.ISSN},
+			{"publisher", c.Publisher},
+			{"doi", c.DOI},
+			{"url", c.URL},
+			{"language", c.Language},
+		} {
+			switch {
+			case f.value == "":
+			case f.name == "author":
+				fmt.Fprintf(sb, ",\n  %s = {%s}", f.name, f.value)
+			case f.name == "doi" || f.name == "url":
+				// these are verbatim fields, only braces would break them
+				fmt.Fprintf(sb, ",\n  %s = {%s}", f.name, strings.NewReplacer("{", "%7B", "}", "%7D").Replace(f.value))
+			default:
+				fmt.Fprintf(sb, ",\n  %s = {%s}", f.name, bibtexEscape(f.value))
+			}
+		}
+		sb.WriteString("\n}\n")
+		if _, err := io.WriteString(w, sb.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bibtexAuthors joins the authors, bracing names that are not in "Family, Given" form, such as organisations, so
+// that they are not split into family and given names
+func bibtexAuthors(authors []string) string {
+	var names []string
+	for _, author := range authors {
+		name := bibtexEscape(author)
+		if name == "" {
+			continue
+		}
+		if _, _, ok := splitName(author); !ok {
+			name = "{" + name + "}"
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, " and ")
+}
+
+// bibtexKey keeps only the characters safe in a citation key
+func bibtexKey(id string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == ':' {
+			return r
+		}
+		return -1
+	}, id)
+}
+
+var bibtexReplacer = strings.NewReplacer(
+	`\`, `\textbackslash{}`, "{", `\{`, "}", `\}`, "&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`, "_", `\_`,
+	"~", `\textasciitilde{}`, "^", `\textasciicircum{}`,
+)
+
+func bibtexEscape(s string) string {
+	return bibtexReplacer.Replace(strings.Join(strings.Fields(s), " "))
+}
+
+// ExportRIS writes the items as RIS records
+func ExportRIS(w io.Writer, items ...Citable) error {
+	for _, item := range items {
+		c := item.citation()
+		sb := &strings.Builder{}
+		risLine := func(tag, value string) {
+			if value = strings.Join(strings.Fields(value), " "); value != "" {
+				fmt.Fprintf(sb, "%s  - %s\r\n", tag, value)
+			}
+		}
+		risLine("TY", map[string]string{"thesis": "THES", "article": "JOUR", "misc": "GEN"}[c.kind()])
+		risLine("ID", c.ID)
+		risLine("TI", c.Title)
+		for _, author := range c.Authors {
+			risLine("AU", author)
+		}
+		risLine("PY", yearString(c.Year))
+		risLine("JO", c.Journal)
+		risLine("SN", c.ISSN)
+		risLine("PB", c.Publisher)
+		risLine("DO", c.DOI)
+		risLine("UR", c.URL)
+		risLine("LA", c.Language)
+		sb.WriteString("ER  - \r\n")
+		if _, err := io.WriteString(w, sb.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cslItem is an item of CSL-JSON, as used by citeproc and reference managers such as Zotero
+type cslItem struct {
+	ID             string    `json:"id"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title,omitempty"`
+	Author         []cslName `json:"author,omitempty"`
+	Issued         *cslDate  `json:"issued,omitempty"`
+	ContainerTitle string    `json:"container-title,omitempty"`
+	ISSN           string    `json:"ISSN,omitempty"`
+	Publisher      string    `json:"publisher,omitempty"`
+	DOI            string    `json:"DOI,omitempty"`
+	URL            string    `json:"URL,omitempty"`
+	Language       string    `json:"language,omitempty"`
+}
+
+type cslName struct {
+	Family  string `json:"family,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Literal string `json:"literal,omitempty"`
+}
+
+type cslDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+// ExportCSLJSON writes the items as a CSL-JSON array
+func ExportCSLJSON(w io.Writer, items ...Citable) error {
+	res := []cslItem{}
+	for _, item := range items {
+		c := item.citation()
+		ci := cslItem{
+			ID:             c.ID,
+			Type:           map[string]string{"thesis": "thesis", "article": "article-journal", "misc": "article"}[c.kind()],
+			Title:          c.Title,
+			ContainerTitle: c.Journal,
+			ISSN:           c.ISSN,
+			Publisher:      c.Publisher,
+			DOI:            c.DOI,
+			URL:            c.URL,
+			Language:       c.Language,
+		}
+		for _, author := range c.Authors {
+			if family, given, ok := splitName(author); ok {
+				ci.Author = append(ci.Author, cslName{Family: family, Given: given})
+			} else {
+				ci.Author = append(ci.Author, cslName{Literal: strings.TrimSpace(author)})
+			}
+		}
+		if c.Year > 0 {
+			ci.Issued = &cslDate{DateParts: [][]int{{c.Year}}}
+		}
+		res = append(res, ci)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// The namespaces of OAI Dublin Core
+const (
+	nsOAIDC = "http://www.openarchives.org/OAI/2.0/oai_dc/"
+	nsDC    = "http://purl.org/dc/elements/1.1/"
+)
+
+type dcRecords struct {
+	XMLName xml.Name   `xml:"records"`
+	Records []dcRecord `xml:"oai_dc:dc"`
+}
+
+// dcRecord is a record in the oai_dc format, the simple Dublin Core used by OAI-PMH
+type dcRecord struct {
+	XMLNSOAIDC string   `xml:"xmlns:oai_dc,attr"`
+	XMLNSDC    string   `xml:"xmlns:dc,attr"`
+	Title      string   `xml:"dc:title,omitempty"`
+	Creators   []string `xml:"dc:creator"`
+	Date       string   `xml:"dc:date,omitempty"`
+	Publisher  string   `xml:"dc:publisher,omitempty"`
+	Type       string   `xml:"dc:type,omitempty"`
+	Language   string   `xml:"dc:language,omitempty"`
+	Identifier []string `xml:"dc:identifier"`
+	Source     []string `xml:"dc:source"`
+}
+
+// ExportDublinCore writes the items as oai_dc Dublin Core records, gathered in a records element
+func ExportDublinCore(w io.Writer, items ...Citable) error {
+	res := dcRecords{}
+	for _, item := range items {
+		c := item.citation()
+		rec := dcRecord{
+			XMLNSOAIDC: nsOAIDC,
+			XMLNSDC:    nsDC,
+			Title:      c.Title,
+			Creators:   c.Authors,
+			Date:       yearString(c.Year),
+			Publisher:  c.Publisher,
+			Type:       map[string]string{"thesis": "thesis", "article": "article", "misc": "text"}[c.kind()],
+			Language:   c.Language,
+		}
+		if c.DOI != "" {
+			rec.Identifier = append(rec.Identifier, "https://doi.org/"+c.DOI)
+		}
+		if c.URL != "" {
+			rec.Identifier = append(rec.Identifier, c.URL)
+		}
+		if c.Journal != "" {
+			rec.Source = append(rec.Source, c.Journal)
+		}
+		if c.ISSN != "" {
+			rec.Source = append(rec.Source, "issn:"+c.ISSN)
+		}
+		res.Records = append(res.Records, rec)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(res); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func yearString(year int) string {
+	if year <= 0 {
+		return ""
+	}
+	return strconv.Itoa(year)
+}
@@ -0,0 +1,202 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	testData := []struct {
+		tag    string
+		export func(w io.Writer, items ...Citable) error
+		exp    string
+	}{
+		{tag: "bibtex", export: ExportBibTeX, exp: expBibTeX},
+		{tag: "ris", export: ExportRIS, exp: expRIS},
+		{tag: "csl-json", export: ExportCSLJSON, exp: expCSLJSON},
+		{tag: "dublin core", export: ExportDublinCore, exp: expDublinCore},
+	}
+	for _, td := range testData {
+		buf := &bytes.Buffer{}
+		require.Nil(t, td.export(buf, testExportFSArticle, &expArticleWrapper.Data), td.tag)
+		assert.Equal(t, td.exp, buf.String(), td.tag)
+		assert.NotNil(t, td.export(failingWriter{}, testExportFSArticle), td.tag)
+	}
+}
+
+func TestExportEmpty(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.Nil(t, ExportCSLJSON(buf))
+	assert.Equal(t, "[]\n", buf.String())
+	buf.Reset()
+	require.Nil(t, ExportBibTeX(buf))
+	assert.Equal(t, "", buf.String())
+}
+
+func TestCitation(t *testing.T) {
+	testData := []struct {
+		tag  string
+		item Citable
+		exp  citation
+	}{
+		{
+			tag: "fast sync fallbacks",
+			item: &FSArticle{CoreID: "1", Identifiers: []string{"oai:x:1", "doi:10.1000/ABC"}, URLs: []string{"http://x/1"},
+				Journals:    []FSJournal{{Identifiers: []string{"eissn", "issn:1234-5679"}}},
+				Enrichments: FSArticleEnrichment{DocType: FSDocType{Type: "thesis"}}},
+			exp: citation{ID: "1", Thesis: true, DOI: "10.1000/abc", URL: "http://x/1", ISSN: "1234-5679"},
+		},
+		{
+			tag:  "fast sync preferred",
+			item: &FSArticle{CoreID: "2", DOI: "10.2000/x", Identifiers: []string{"10.1000/y"}, ISSN: "0143-7739", DownloadURL: "http://x/2.pdf", URLs: []string{"http://x/2"}},
+			exp:  citation{ID: "2", DOI: "10.2000/x", URL: "http://x/2.pdf", ISSN: "0143-7739"},
+		},
+		{
+			tag:  "article",
+			item: &Article{ID: "3", Identifiers: []string{"https://doi.org/10.3000/z"}, Types: []string{"Thesis"}, FullTextURLs: []string{"http://x/3"}},
+			exp:  citation{ID: "3", Thesis: true, DOI: "10.3000/z", URL: "http://x/3"},
+		},
+		{
+			tag:  "doi that cannot be normalised",
+			item: &FSArticle{CoreID: "4", Identifiers: []string{"oai:x:4", "doi:10.1/ABC"}},
+			exp:  citation{ID: "4", DOI: "10.1/abc"},
+		},
+	}
+	for _, td := range testData {
+		assert.Equal(t, td.exp, td.item.citation(), td.tag)
+	}
+}
+
+//
+// Test Data
+//
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+var testExportFSArticle = &FSArticle{
+	CoreID:      "42",
+	DOI:         "10.1234/abc_1",
+	Title:       "Cats & Dogs: {A} 100% study",
+	Authors:     []string{"Wood, Marsha", "CORE Team"},
+	Year:        2010,
+	Publisher:   "NSPCC",
+	DownloadURL: "https://core.ac.uk/download/pdf/42.pdf",
+	Journals:    []FSJournal{{Title: "Journal of Pets", Identifiers: []string{"issn:0143-7739"}}},
+	Language:    FSLanguage{Code: "en"},
+}
+
+var expBibTeX = `@article{core42,
+  title = {Cats \& Dogs: \{A\} 100\% study},
+  author = {Wood, Marsha and {CORE Team}},
+  year = {2010},
+  journal = {Journal of Pets},
+  issn = {0143-7739},
+  publisher = {NSPCC},
+  doi = {10.1234/abc_1},
+  url = {https://core.ac.uk/download/pdf/42.pdf},
+  language = {en}
+}
+
+@misc{core123456,
+  title = {This is a long and complicated article},
+  author = {Davies, Nathan},
+  year = {2012},
+  publisher = {Publishing House},
+  url = {https://example.com/download/pdf/123456.pdf},
+  language = {et}
+}
+`
+
+var expRIS = "TY  - JOUR\r\nID  - 42\r\nTI  - Cats & Dogs: {A} 100% study\r\nAU  - Wood, Marsha\r\nAU  - CORE Team\r\n" +
+	"PY  - 2010\r\nJO  - Journal of Pets\r\nSN  - 0143-7739\r\nPB  - NSPCC\r\nDO  - 10.1234/abc_1\r\n" +
+	"UR  - https://core.ac.uk/download/pdf/42.pdf\r\nLA  - en\r\nER  - \r\n" +
+	"TY  - GEN\r\nID  - 123456\r\nTI  - This is a long and complicated article\r\nAU  - Davies, Nathan\r\nPY  - 2012\r\n" +
+	"PB  - Publishing House\r\nUR  - https://example.com/download/pdf/123456.pdf\r\nLA  - et\r\nER  - \r\n"
+
+var expCSLJSON = `[
+  {
+    "id": "42",
+    "type": "article-journal",
+    "title": "Cats & Dogs: {A} 100% study",
+    "author": [
+      {
+        "family": "Wood",
+        "given": "Marsha"
+      },
+      {
+        "literal": "CORE Team"
+      }
+    ],
+    "issued": {
+      "date-parts": [
+        [
+          2010
+        ]
+      ]
+    },
+    "container-title": "Journal of Pets",
+    "ISSN": "0143-7739",
+    "publisher": "NSPCC",
+    "DOI": "10.1234/abc_1",
+    "URL": "https://core.ac.uk/download/pdf/42.pdf",
+    "language": "en"
+  },
+  {
+    "id": "123456",
+    "type": "article",
+    "title": "This is a long and complicated article",
+    "author": [
+      {
+        "family": "Davies",
+        "given": "Nathan"
+      }
+    ],
+    "issued": {
+      "date-parts": [
+        [
+          2012
+        ]
+      ]
+    },
+    "publisher": "Publishing House",
+    "URL": "https://example.com/download/pdf/123456.pdf",
+    "language": "et"
+  }
+]
+`
+
+var expDublinCore = `<?xml version="1.0" encoding="UTF-8"?>
+<records>
+  <oai_dc:dc xmlns:oai_dc="http://www.openarchives.org/OAI/2.0/oai_dc/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Cats &amp; Dogs: {A} 100% study</dc:title>
+    <dc:creator>Wood, Marsha</dc:creator>
+    <dc:creator>CORE Team</dc:creator>
+    <dc:date>2010</dc:date>
+    <dc:publisher>NSPCC</dc:publisher>
+    <dc:type>article</dc:type>
+    <dc:language>en</dc:language>
+    <dc:identifier>https://doi.org/10.1234/abc_1</dc:identifier>
+    <dc:identifier>https://core.ac.uk/download/pdf/42.pdf</dc:identifier>
+    <dc:source>Journal of Pets</dc:source>
+    <dc:source>issn:0143-7739</dc:source>
+  </oai_dc:dc>
+  <oai_dc:dc xmlns:oai_dc="http://www.openarchives.org/OAI/2.0/oai_dc/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>This is a long and complicated article</dc:title>
+    <dc:creator>Davies, Nathan</dc:creator>
+    <dc:date>2012</dc:date>
+    <dc:publisher>Publishing House</dc:publisher>
+    <dc:type>text</dc:type>
+    <dc:language>et</dc:language>
+    <dc:identifier>https://example.com/download/pdf/123456.pdf</dc:identifier>
+  </oai_dc:dc>
+</records>
+`
@@ -0,0 +1,409 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RecordError reports a record of a fast sync file which could not be decoded, and where it is in the input
+type RecordError struct {
+	Offset int64 // the byte offset of the start of the record
+	Line   int   // the line the record starts on, counting from 1
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("malformed record at offset %d, line %d: %v", e.Offset, e.Line, e.Err)
+}
+
+// FSArticleReader decodes FastSync articles one at a time from a stream, so that files of any size can be processed
+// in constant memory. The stream holds either newline delimited JSON, one article per line, or a single JSON array of
+// articles; which is detected from the first character. Records are found by matching brackets, so articles spread
+// over several lines, or simply concatenated, are also read. A leading UTF-8 byte order mark is ignored.
+//
+// A record that cannot be decoded is returned from Read as a *RecordError, after which reading may continue with the
+// next record. If SkipBad is set such records are skipped instead, counted by Skipped and passed to OnSkip.
+//
+// SkipFields names top level fields, by their JSON keys ignoring case, that are passed over rather than decoded. Their values are
+// never held in memory, so skipping HeavyFSArticleFields makes scanning a dump for identifiers or titles much cheaper.
+// The options must be set before the first Read.
+type FSArticleReader struct {
+	SkipBad    bool
+	OnSkip     func(err *RecordError) // optional, called for each record skipped
+	SkipFields []string
+
+	r       *bufio.Reader
+	offset  int64 // of the next byte to be read
+	line    int
+	started bool
+	array   bool
+	done    bool
+	skipped int
+	buf     []byte
+	skip    map[string]bool
+
+	// outside an array, whether the records are known to be one per line, decided by the first record read
+	lineDelimited bool
+	layoutKnown   bool
+}
+
+// HeavyFSArticleFields are the fields of an FSArticle which can run to megabytes, for use as SkipFields
+var HeavyFSArticleFields = []string{"fullText", "rawRecordXML"}
+
+// NewFSArticleReader returns a reader decoding articles from r
+func NewFSArticleReader(r io.Reader) *FSArticleReader {
+	return &FSArticleReader{r: bufio.NewReader(r), line: 1}
+}
+
+// Read returns the next article, or io.EOF once there are no more. Errors other than a *RecordError come from the
+// underlying reader and end the reading.
+func (fr *FSArticleReader) Read() (*FSArticle, error) {
+	for {
+		raw, offset, line, err := fr.next()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err == nil {
+			res := &FSArticle{}
+			if err = json.Unmarshal(raw, res); err == nil {
+				return res, nil
+			}
+		}
+		if ioErr, ok := err.(ioError); ok {
+			fr.done = true
+			return nil, ioErr.err
+		}
+		recErr := &RecordError{Offset: offset, Line: line, Err: err}
+		if !fr.SkipBad {
+			return nil, recErr
+		}
+		fr.skipped++
+		if fr.OnSkip != nil {
+			fr.OnSkip(recErr)
+		}
+	}
+}
+
+// Skipped is the number of records skipped so far, always 0 unless SkipBad is set
+func (fr *FSArticleReader) Skipped() int {
+	return fr.skipped
+}
+
+// ioError marks a failure of the underlying reader, distinguishing it from a malformed record
+type ioError struct {
+	err error
+}
+
+func (e ioError) Error() string {
+	return e.err.Error()
+}
+
+// next reads the raw bytes of the next record, along with the offset and line it starts at
+func (fr *FSArticleReader) next() ([]byte, int64, int, error) {
+	if fr.done {
+		return nil, fr.offset, fr.line, io.EOF
+	}
+	if !fr.started {
+		fr.started = true
+		if len(fr.SkipFields) > 0 {
+			fr.skip = map[string]bool{}
+			for _, key := range fr.SkipFields {
+				fr.skip[strings.ToLower(key)] = true
+			}
+		}
+		// a byte order mark is passed over, JSON is always UTF-8
+		if bom, _ := fr.r.Peek(len(utf8BOM)); string(bom) == utf8BOM {
+			fr.r.Discard(len(utf8BOM))
+			fr.offset += int64(len(utf8BOM))
+		}
+		b, err := fr.skipSpace(false)
+		if err != nil {
+			return nil, fr.offset, fr.line, fr.end(err, false)
+		}
+		if b == '[' {
+			fr.readByte()
+			fr.array = true
+		}
+	}
+	b, err := fr.skipSpace(fr.array)
+	if err != nil {
+		return nil, fr.offset, fr.line, fr.end(err, fr.array)
+	}
+	if fr.array && b == ']' {
+		fr.readByte()
+		fr.done = true
+		return nil, fr.offset, fr.line, io.EOF
+	}
+	offset, line := fr.offset, fr.line
+	fr.buf = fr.buf[:0]
+	if b == '{' && fr.skip != nil {
+		err = fr.readObject()
+	} else {
+		err = fr.scanValue(true, 0)
+	}
+	if !fr.array && !fr.layoutKnown && err == nil {
+		fr.layoutKnown = true
+		fr.lineDelimited = fr.line == line
+	}
+	return fr.buf, offset, line, err
+}
+
+// utf8BOM is the byte order mark some tools write at the start of a UTF-8 file
+const utf8BOM = "\xef\xbb\xbf"
+
+// end handles reaching the end of the input between records, which is only expected outside an array
+func (fr *FSArticleReader) end(err error, inArray bool) error {
+	fr.done = true
+	if err != io.EOF {
+		return ioError{err}
+	}
+	if inArray {
+		return io.ErrUnexpectedEOF
+	}
+	return io.EOF
+}
+
+// skipSpace advances over whitespace, and between the records of an array, commas. The next byte is returned
+// without being consumed.
+func (fr *FSArticleReader) skipSpace(commas bool) (byte, error) {
+	for {
+		b, err := fr.readByte()
+		if err != nil {
+			return 0, err
+		}
+		if isSpace(b) || (commas && b == ',') {
+			continue
+		}
+		fr.unreadByte(b)
+		return b, nil
+	}
+}
+
+// readObject reads an object into buf field by field, leaving out the fields to skip. Insignificant whitespace is
+// dropped, and the values are left to json.Unmarshal to validate.
+func (fr *FSArticleReader) readObject() error {
+	fr.readByte()
+	fr.buf = append(fr.buf, '{')
+	for {
+		b, err := fr.skipRecordSpace(false)
+		if err != nil {
+			return fr.objectEnd(err)
+		}
+		if b == '}' {
+			fr.readByte()
+			fr.buf = append(fr.buf, '}')
+			return nil
+		}
+		if b != '"' {
+			return fr.malformedObject(b)
+		}
+		keyStart := len(fr.buf)
+		if err := fr.scanValue(true, 0); err != nil {
+			return err
+		}
+		// keys are matched ignoring case, as json.Unmarshal does
+		skip := fr.skip[strings.ToLower(string(fr.buf[keyStart+1:len(fr.buf)-1]))]
+		if b, err = fr.skipRecordSpace(false); err != nil {
+			return fr.objectEnd(err)
+		}
+		if b != ':' {
+			return fr.malformedObject(b)
+		}
+		fr.readByte()
+		if skip {
+			fr.buf = fr.buf[:keyStart]
+		} else {
+			fr.buf = append(fr.buf, ':')
+		}
+		if _, err = fr.skipRecordSpace(false); err != nil {
+			return fr.objectEnd(err)
+		}
+		if err := fr.scanValue(!skip, 0); err != nil {
+			return err
+		}
+		if b, err = fr.skipRecordSpace(true); err != nil {
+			return fr.objectEnd(err)
+		}
+		switch b {
+		case ',':
+			fr.readByte()
+			if !skip {
+				fr.buf = append(fr.buf, ',')
+			}
+		case '}':
+			// a skipped last field leaves the comma that followed the previous one
+			if n := len(fr.buf); fr.buf[n-1] == ',' {
+				fr.buf = fr.buf[:n-1]
+			}
+		default:
+			return fr.malformedObject(b)
+		}
+	}
+}
+
+// skipRecordSpace is skipSpace within an object being read by readObject. Outside an array a record running on past
+// the end of its line is reported as errTruncatedLine, as scanValue does, where afterValue is set if a comma or
+// closing brace is expected next.
+func (fr *FSArticleReader) skipRecordSpace(afterValue bool) (byte, error) {
+	line := fr.line
+	b, err := fr.skipSpace(false)
+	if err != nil || fr.array || fr.line == line {
+		return b, err
+	}
+	if fr.lineDelimited || (afterValue && b != ',' && b != '}') {
+		return b, errTruncatedLine
+	}
+	return b, nil
+}
+
+// objectEnd reports the input ending part way through an object, or its line ending part way through
+func (fr *FSArticleReader) objectEnd(err error) error {
+	if err == errTruncatedLine {
+		return err
+	}
+	if err == io.EOF {
+		fr.done = true
+		return io.ErrUnexpectedEOF
+	}
+	return ioError{err}
+}
+
+// Errors for a record cut short at the end of its line, outside an array
+var (
+	errUnterminatedString = errors.New("unterminated string at end of line")
+	errTruncatedLine      = errors.New("record cut short at end of line")
+)
+
+// malformedObject passes over the rest of an object holding an unexpected character, so the next record can be read
+func (fr *FSArticleReader) malformedObject(b byte) error {
+	offset := fr.offset
+	if err := fr.scanValue(false, 1); err != nil {
+		return err
+	}
+	return fmt.Errorf("invalid character %q at offset %d", b, offset)
+}
+
+// scanValue reads a single JSON value by matching brackets and quotes, leaving its validation to json.Unmarshal.
+// The value is appended to buf if keep is set and otherwise passed over. A value ends at its closing bracket or
+// quote, or for a bare scalar at the next separator. depth is the number of brackets already open.
+//
+// Outside an array a newline within a string, which JSON never allows, ends the record as malformed. So does a
+// newline within brackets once the records are known to be one per line, or, before that is known, one that cannot
+// be part of a record spread over several lines: following a value without a separator or closing bracket after
+// it. A line cut short then costs only that record, reading resuming with the next line.
+func (fr *FSArticleReader) scanValue(keep bool, depth int) error {
+	n := 0
+	inString, escaped := false, false
+	var prev byte // the last byte outside whitespace and strings, or the quote closing a string
+	for {
+		b, err := fr.readByte()
+		if err == io.EOF && depth == 0 && !inString && n > 0 {
+			return nil
+		}
+		if err == io.EOF {
+			fr.done = true
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return ioError{err}
+		}
+		n++
+		if keep {
+			fr.buf = append(fr.buf, b)
+		}
+		if inString {
+			switch {
+			case b == '\n' && !fr.array:
+				return errUnterminatedString
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+				prev = b
+				if depth == 0 {
+					return nil
+				}
+			}
+			continue
+		}
+		if b == '\n' && depth > 0 && !fr.array && fr.cutShort(prev) {
+			return errTruncatedLine
+		}
+		if !isSpace(b) {
+			prev = b
+		}
+		switch {
+		case b == '"':
+			inString = true
+		case b == '{' || b == '[':
+			depth++
+		case (b == '}' || b == ']') && depth > 0:
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		case depth == 0 && (isSpace(b) || b == ',' || b == ']' || b == '}'):
+			if n == 1 {
+				// a stray separator, consumed as a malformed record of its own so that reading moves on
+				return nil
+			}
+			if keep {
+				fr.buf = fr.buf[:len(fr.buf)-1]
+			}
+			fr.unreadByte(b)
+			return nil
+		}
+	}
+}
+
+// cutShort reports whether a newline within brackets, after prev, ends a record that was cut short
+func (fr *FSArticleReader) cutShort(prev byte) bool {
+	if fr.lineDelimited {
+		return true
+	}
+	switch prev {
+	case 0, '{', '[', ',', ':':
+		return false
+	}
+	// after a value a record spread over lines carries on with a separator or closing bracket
+	for n := 1; ; n++ {
+		next, err := fr.r.Peek(n)
+		if err != nil {
+			return false
+		}
+		if b := next[n-1]; !isSpace(b) {
+			return b != ',' && b != '}' && b != ']'
+		}
+	}
+}
+
+func (fr *FSArticleReader) readByte() (byte, error) {
+	b, err := fr.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	fr.offset++
+	if b == '\n' {
+		fr.line++
+	}
+	return b, nil
+}
+
+// unreadByte steps back over b, which must be the byte just read
+func (fr *FSArticleReader) unreadByte(b byte) {
+	fr.r.UnreadByte()
+	fr.offset--
+	if b == '\n' {
+		fr.line--
+	}
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n'
+}
@@ -0,0 +1,286 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFSArticleReader(t *testing.T) {
+	compact := &bytes.Buffer{}
+	require.Nil(t, json.Compact(compact, testFSData))
+
+	testData := []struct {
+		tag   string
+		input string
+	}{
+		{tag: "ndjson", input: compact.String() + "\n" + compact.String() + "\n"},
+		{tag: "ndjson no trailing newline", input: compact.String() + "\r\n" + compact.String()},
+		{tag: "concatenated, pretty printed", input: string(testFSData) + string(testFSData)},
+		{tag: "array", input: "[" + compact.String() + "," + compact.String() + "]"},
+		{tag: "array, pretty printed", input: "\n[\n  " + string(testFSData) + ",\n  " + string(testFSData) + "\n]\n"},
+	}
+	for _, td := range testData {
+		fr := NewFSArticleReader(strings.NewReader(td.input))
+		for i := 0; i < 2; i++ {
+			article, err := fr.Read()
+			require.Nil(t, err, td.tag)
+			assert.Equal(t, expFSArticle, article, td.tag)
+		}
+		_, err := fr.Read()
+		assert.Equal(t, io.EOF, err, td.tag)
+		_, err = fr.Read()
+		assert.Equal(t, io.EOF, err, td.tag+": stays at the end")
+	}
+}
+
+func TestFSArticleReaderBOM(t *testing.T) {
+	for _, input := range []string{"\xef\xbb\xbf{\"coreId\": \"1\"}\n", "\xef\xbb\xbf[{\"coreId\": \"1\"}]"} {
+		fr := NewFSArticleReader(strings.NewReader(input))
+		article, err := fr.Read()
+		require.Nil(t, err, input)
+		assert.Equal(t, "1", article.CoreID, input)
+		_, err = fr.Read()
+		assert.Equal(t, io.EOF, err, input)
+	}
+	_, err := NewFSArticleReader(strings.NewReader("\xef\xbb\xbf{\"coreId\": tru}")).Read()
+	require.IsType(t, &RecordError{}, err)
+	assert.Equal(t, int64(3), err.(*RecordError).Offset, "offsets count the byte order mark")
+}
+
+func TestFSArticleReaderEmpty(t *testing.T) {
+	for _, input := range []string{"", "  \n", "[]", " [ ] "} {
+		_, err := NewFSArticleReader(strings.NewReader(input)).Read()
+		assert.Equal(t, io.EOF, err, input)
+	}
+}
+
+func TestFSArticleReaderMalformed(t *testing.T) {
+	testData := []struct {
+		tag       string
+		input     string
+		expIDs    []string
+		expErrors []RecordError // the offset and line of each error, in order
+	}{
+		{
+			tag:       "ndjson syntax",
+			input:     "{\"coreId\": \"1\"}\n{\"coreId\": tru}\n{\"coreId\": \"3\"}\n",
+			expIDs:    []string{"1", "3"},
+			expErrors: []RecordError{{Offset: 16, Line: 2}},
+		},
+		{
+			tag:       "ndjson wrong type",
+			input:     "{\"coreId\": \"1\"}\n{\"year\": \"2019\"}\n{\"coreId\": \"3\"}\n",
+			expIDs:    []string{"1", "3"},
+			expErrors: []RecordError{{Offset: 16, Line: 2}},
+		},
+		{
+			tag:       "ndjson not an object",
+			input:     "{\"coreId\": \"1\"}\noops\n}\n{\"coreId\": \"3\"}",
+			expIDs:    []string{"1", "3"},
+			expErrors: []RecordError{{Offset: 16, Line: 2}, {Offset: 21, Line: 3}},
+		},
+		{
+			tag:       "ndjson truncated",
+			input:     "{\"coreId\": \"1\"}\n{\"coreId\": \"2",
+			expIDs:    []string{"1"},
+			expErrors: []RecordError{{Offset: 16, Line: 2}},
+		},
+		{
+			tag:       "ndjson line cut short in a string",
+			input:     "{\"coreId\":\"1\",\"title\":\"x\n{\"coreId\": \"2\"}\n{\"coreId\": \"3\"}\n{\"coreId\": \"4\"}\n",
+			expIDs:    []string{"2", "3", "4"},
+			expErrors: []RecordError{{Offset: 0, Line: 1}},
+		},
+		{
+			tag:       "ndjson cut short in a string mid file",
+			input:     "{\"coreId\": \"1\"}\n{\"coreId\": \"2\", \"authors\": [\"Wood, M\n{\"coreId\": \"3\"}\n",
+			expIDs:    []string{"1", "3"},
+			expErrors: []RecordError{{Offset: 16, Line: 2}},
+		},
+		{
+			tag:       "ndjson first line cut short after a value",
+			input:     "{\"doi\":\"10.1/a\",\"title\":\"one\"\n{\"coreId\": \"2\"}\n{\"coreId\": \"3\"}\n",
+			expIDs:    []string{"2", "3"},
+			expErrors: []RecordError{{Offset: 0, Line: 1}},
+		},
+		{
+			tag:       "ndjson line cut short mid file",
+			input:     "{\"coreId\": \"1\"}\n{\"coreId\": \"2\", \"authors\": [\"Wood, M\", \n{\"coreId\": \"3\"}\n{\"coreId\": \"4\"}",
+			expIDs:    []string{"1", "3", "4"},
+			expErrors: []RecordError{{Offset: 16, Line: 2}},
+		},
+		{
+			tag:       "array wrong type",
+			input:     "[{\"coreId\": \"1\"},\n {\"year\": [1]},\n {\"coreId\": \"3\"}]",
+			expIDs:    []string{"1", "3"},
+			expErrors: []RecordError{{Offset: 19, Line: 2}},
+		},
+		{
+			tag:       "array scalar",
+			input:     "[{\"coreId\": \"1\"}, 42, \"x\", {\"coreId\": \"3\"}]",
+			expIDs:    []string{"1", "3"},
+			expErrors: []RecordError{{Offset: 18, Line: 1}, {Offset: 22, Line: 1}},
+		},
+		{
+			tag:       "array unterminated",
+			input:     "[{\"coreId\": \"1\"},\n",
+			expIDs:    []string{"1"},
+			expErrors: []RecordError{{Offset: 18, Line: 2}},
+		},
+	}
+	for _, td := range testData {
+		// without SkipBad each error is returned, and reading can carry on past it
+		fr := NewFSArticleReader(strings.NewReader(td.input))
+		var ids []string
+		var errs []RecordError
+		for {
+			article, err := fr.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				recErr, ok := err.(*RecordError)
+				require.True(t, ok, td.tag)
+				assert.NotNil(t, recErr.Err, td.tag)
+				errs = append(errs, RecordError{Offset: recErr.Offset, Line: recErr.Line})
+				continue
+			}
+			ids = append(ids, article.CoreID)
+		}
+		assert.Equal(t, td.expIDs, ids, td.tag)
+		assert.Equal(t, td.expErrors, errs, td.tag)
+		assert.Equal(t, 0, fr.Skipped(), td.tag)
+
+		// with SkipBad the errors are only reported to OnSkip
+		fr = NewFSArticleReader(strings.NewReader(td.input))
+		fr.SkipBad = true
+		var skipped []RecordError
+		fr.OnSkip = func(err *RecordError) {
+			skipped = append(skipped, RecordError{Offset: err.Offset, Line: err.Line})
+		}
+		ids = nil
+		for {
+			article, err := fr.Read()
+			if err == io.EOF {
+				break
+			}
+			require.Nil(t, err, td.tag)
+			ids = append(ids, article.CoreID)
+		}
+		assert.Equal(t, td.expIDs, ids, td.tag+": skip bad")
+		assert.Equal(t, td.expErrors, skipped, td.tag+": skip bad")
+		assert.Equal(t, len(td.expErrors), fr.Skipped(), td.tag+": skip bad")
+	}
+}
+
+func TestFSArticleReaderIOError(t *testing.T) {
+	failure := errors.New("connection reset")
+	fr := NewFSArticleReader(io.MultiReader(strings.NewReader("{\"coreId\": \"1\"}\n{\"core"), &failingReader{failure}))
+	fr.SkipBad = true
+	article, err := fr.Read()
+	require.Nil(t, err)
+	assert.Equal(t, "1", article.CoreID)
+	_, err = fr.Read()
+	assert.Equal(t, failure, err, "read failures are not skipped")
+	_, err = fr.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestFSArticleReaderSkipFields(t *testing.T) {
+	expSkipped := *expFSArticle
+	expSkipped.FullText = ""
+	expSkipped.RawRecordXML = ""
+	fr := NewFSArticleReader(bytes.NewReader(append(append([]byte{}, testFSData...), testFSData...)))
+	fr.SkipFields = HeavyFSArticleFields
+	for i := 0; i < 2; i++ {
+		article, err := fr.Read()
+		require.Nil(t, err)
+		assert.Equal(t, &expSkipped, article)
+	}
+	_, err := fr.Read()
+	assert.Equal(t, io.EOF, err)
+
+	testData := []struct {
+		tag   string
+		input string
+		exp   FSArticle
+	}{
+		{tag: "first", input: `{"fullText": "x", "coreId": "1", "title": "T"}`, exp: FSArticle{CoreID: "1", Title: "T"}},
+		{tag: "middle", input: `{"coreId": "1", "fullText": "x", "title": "T"}`, exp: FSArticle{CoreID: "1", Title: "T"}},
+		{tag: "last", input: `{"coreId": "1", "title": "T" , "fullText": "x" }`, exp: FSArticle{CoreID: "1", Title: "T"}},
+		{tag: "only", input: `{"fullText": "x"}`, exp: FSArticle{}},
+		{tag: "adjacent", input: `{"coreId": "1","fullText": "x","rawRecordXML": "<a/>","title": "T"}`,
+			exp: FSArticle{CoreID: "1", Title: "T"}},
+		{tag: "structured value", input: `{"fullText": {"a": ["}", 1]}, "coreId": "1"}`, exp: FSArticle{CoreID: "1"}},
+		{tag: "scalar value", input: `{"fullText": null, "year": 2019}`, exp: FSArticle{Year: 2019}},
+		{tag: "escaped quotes", input: `{"fullText": "a \"quoted\" \\", "coreId": "1"}`, exp: FSArticle{CoreID: "1"}},
+		{tag: "nested keys are kept", input: `{"enrichments": {"fullText": "x", "citationCount": 3}}`,
+			exp: FSArticle{Enrichments: FSArticleEnrichment{CitationCount: 3}}},
+	}
+	for _, td := range testData {
+		fr := NewFSArticleReader(strings.NewReader(td.input))
+		fr.SkipFields = HeavyFSArticleFields
+		article, err := fr.Read()
+		require.Nil(t, err, td.tag)
+		assert.Equal(t, &td.exp, article, td.tag)
+	}
+}
+
+func TestFSArticleReaderSkipFieldsMalformed(t *testing.T) {
+	input := "{\"coreId\": \"1\"}\n{\"coreId\" \"2\", \"x\": {}}\n{\"fullText\": \"x\", \"year\": \"2019\"}\n{coreId: 4}\n" +
+		"{\"coreId\": \"5\",}\n{\"fullText\": \"cut short\n{\"title\": \"T\", \"fullText\": {\"a\": 1}\n{\"coreId\": \"6\", \"fullText\": \"x\"}\n{\"fullText\": \"trunc"
+	fr := NewFSArticleReader(strings.NewReader(input))
+	fr.SkipFields = HeavyFSArticleFields
+	fr.SkipBad = true
+	var lines []int
+	fr.OnSkip = func(err *RecordError) {
+		lines = append(lines, err.Line)
+	}
+	var ids []string
+	for {
+		article, err := fr.Read()
+		if err == io.EOF {
+			break
+		}
+		require.Nil(t, err)
+		ids = append(ids, article.CoreID)
+	}
+	assert.Equal(t, []string{"1", "6"}, ids)
+	assert.Equal(t, []int{2, 3, 4, 5, 6, 7, 9}, lines)
+}
+
+func TestFSArticleReaderSkipFieldsMemory(t *testing.T) {
+	fullText := strings.Repeat("a long body of text ", 1<<18) // 5MB
+	input := io.MultiReader(strings.NewReader(`{"coreId": "1", "fullText": "`), strings.NewReader(fullText),
+		strings.NewReader(`", "title": "T"}`))
+	fr := NewFSArticleReader(input)
+	fr.SkipFields = []string{"fullText"}
+	article, err := fr.Read()
+	require.Nil(t, err)
+	assert.Equal(t, &FSArticle{CoreID: "1", Title: "T"}, article)
+	assert.True(t, cap(fr.buf) < 1024, "the full text was not buffered, buffer capacity %d", cap(fr.buf))
+}
+
+func TestRecordError(t *testing.T) {
+	err := &RecordError{Offset: 10, Line: 2, Err: io.ErrUnexpectedEOF}
+	assert.Equal(t, "malformed record at offset 10, line 2: unexpected EOF", err.Error())
+}
+
+//
+// Test Data
+//
+
+type failingReader struct {
+	err error
+}
+
+func (fr *failingReader) Read(p []byte) (int, error) {
+	return 0, fr.err
+}
@@ -0,0 +1,322 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// The kinds of identifier ClassifyIdentifier recognises
+const (
+	IdentifierDOI    = "doi"
+	IdentifierISSN   = "issn"
+	IdentifierOAI    = "oai"
+	IdentifierPMID   = "pmid"
+	IdentifierArXiv  = "arxiv"
+	IdentifierHandle = "handle"
+	IdentifierURL    = "url"
+)
+
+var (
+	ErrInvalidDOI        = errors.New("not a valid DOI")
+	ErrInvalidISSN       = errors.New("not a valid ISSN")
+	ErrISSNCheckDigit    = errors.New("ISSN check digit does not match")
+	ErrUnknownIdentifier = errors.New("unrecognised identifier")
+)
+
+var (
+	doiPattern       = regexp.MustCompile(`^10\.[0-9]{4,9}(\.[0-9]+)*/\S+$`)
+	issnPattern      = regexp.MustCompile(`^([0-9]{4})-?([0-9]{3}[0-9X])$`)
+	oaiPattern       = regexp.MustCompile(`^oai:[A-Za-z][A-Za-z0-9-]*(\.[A-Za-z0-9-]+)*:\S+$`)
+	pmidPattern      = regexp.MustCompile(`^[0-9]{1,9}$`)
+	arXivPattern     = regexp.MustCompile(`^([0-9]{4}\.[0-9]{4,5}|[a-z-]+(\.[A-Z]{2})?/[0-9]{7})(v[0-9]+)?$`)
+	handlePattern    = regexp.MustCompile(`^[0-9]+(\.[0-9A-Za-z]+)*/\S+$`)
+	identifierPrefix = []struct {
+		kind   string
+		prefix string
+	}{
+		{IdentifierDOI, "doi:"},
+		{IdentifierDOI, "info:doi/"},
+		{IdentifierDOI, "https://doi.org/"},
+		{IdentifierDOI, "http://doi.org/"},
+		{IdentifierDOI, "https://dx.doi.org/"},
+		{IdentifierDOI, "http://dx.doi.org/"},
+		{IdentifierISSN, "issn:"},
+		{IdentifierISSN, "eissn:"},
+		{IdentifierISSN, "pissn:"},
+		{IdentifierPMID, "pmid:"},
+		{IdentifierPMID, "pubmed:"},
+		{IdentifierPMID, "https://pubmed.ncbi.nlm.nih.gov/"},
+		{IdentifierPMID, "https://www.ncbi.nlm.nih.gov/pubmed/"},
+		{IdentifierArXiv, "arxiv:"},
+		{IdentifierArXiv, "https://arxiv.org/abs/"},
+		{IdentifierArXiv, "http://arxiv.org/abs/"},
+		{IdentifierArXiv, "https://arxiv.org/pdf/"},
+		{IdentifierArXiv, "http://arxiv.org/pdf/"},
+		{IdentifierHandle, "hdl:"},
+		{IdentifierHandle, "handle:"},
+		{IdentifierHandle, "https://hdl.handle.net/"},
+		{IdentifierHandle, "http://hdl.handle.net/"},
+	}
+)
+
+// NormaliseDOI returns the DOI in s, which may be bare or given with a "doi:" prefix or as a doi.org URL, in lower
+// case so that equal DOIs compare equal. An error is returned if s does not hold a DOI.
+func NormaliseDOI(s string) (string, error) {
+	doi := strings.TrimSpace(s)
+	lower := strings.ToLower(doi)
+	for _, p := range identifierPrefix {
+		if p.kind == IdentifierDOI && strings.HasPrefix(lower, p.prefix) {
+			doi = strings.TrimSpace(doi[len(p.prefix):])
+			if strings.HasPrefix(p.prefix, "http") {
+				if unescaped, err := url.PathUnescape(doi); err == nil {
+					doi = unescaped
+				}
+			}
+			break
+		}
+	}
+	doi = strings.ToLower(doi)
+	if !doiPattern.MatchString(doi) {
+		return "", ErrInvalidDOI
+	}
+	return doi, nil
+}
+
+// NormaliseISSN returns the ISSN in s, which may have an "issn:" prefix and need not be hyphenated, in the form
+// 0143-7739. An error is returned if s is not an ISSN or its check digit does not match.
+func NormaliseISSN(s string) (string, error) {
+	issn := strings.TrimSpace(s)
+	lower := strings.ToLower(issn)
+	for _, p := range identifierPrefix {
+		if p.kind == IdentifierISSN && strings.HasPrefix(lower, p.prefix) {
+			issn = strings.TrimSpace(issn[len(p.prefix):])
+			break
+		}
+	}
+	m := issnPattern.FindStringSubmatch(strings.ToUpper(issn))
+	if m == nil {
+		return "", ErrInvalidISSN
+	}
+	issn = m[1] + "-" + m[2]
+	if issnCheckDigit(m[1]+m[2][:3]) != m[2][3] {
+		return "", ErrISSNCheckDigit
+	}
+	return issn, nil
+}
+
+// issnCheckDigit calculates the check digit of the first seven digits of an ISSN, a weighted sum modulo 11
+func issnCheckDigit(digits string) byte {
+	sum := 0
+	for i, d := range digits {
+		sum += int(d-'0') * (8 - i)
+	}
+	switch check := (11 - sum%11) % 11; check {
+	case 10:
+		return 'X'
+	default:
+		return byte('0' + check)
+	}
+}
+
+// ClassifyIdentifier works out which kind of identifier an untyped identifier string is, returning the kind along
+// with the identifier normalised: DOIs and ISSNs as NormaliseDOI and NormaliseISSN give them, and the others with any
+// prefix or resolver URL removed. An error is returned if the identifier is malformed or is not of a kind recognised.
+//
+// A bare ISSN is not recognised, as it cannot be told apart from other numbers; use NormaliseISSN where an ISSN is
+// expected.
+func ClassifyIdentifier(s string) (kind, value string, err error) {
+	id := strings.TrimSpace(s)
+	lower := strings.ToLower(id)
+	for _, p := range identifierPrefix {
+		if strings.HasPrefix(lower, p.prefix) {
+			kind, value = p.kind, strings.TrimSpace(id[len(p.prefix):])
+			break
+		}
+	}
+	switch {
+	case kind != "":
+	case strings.HasPrefix(lower, "10."):
+		kind = IdentifierDOI
+	case strings.HasPrefix(lower, "oai:"):
+		kind, value = IdentifierOAI, id
+	case strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") ||
+		strings.HasPrefix(lower, "ftp://"):
+		kind, value = IdentifierURL, id
+	default:
+		return "", "", ErrUnknownIdentifier
+	}
+	var valid bool
+	switch kind {
+	case IdentifierDOI:
+		value, err = NormaliseDOI(id)
+		return kind, value, err
+	case IdentifierISSN:
+		value, err = NormaliseISSN(id)
+		return kind, value, err
+	case IdentifierOAI:
+		valid = oaiPattern.MatchString(value)
+	case IdentifierPMID:
+		value = strings.TrimSuffix(value, "/")
+		valid = pmidPattern.MatchString(value)
+	case IdentifierArXiv:
+		value = strings.TrimSuffix(value, ".pdf")
+		valid = arXivPattern.MatchString(value)
+	case IdentifierHandle:
+		valid = handlePattern.MatchString(value)
+	case IdentifierURL:
+		u, err := url.Parse(value)
+		valid = err == nil && u.Host != ""
+	}
+	if !valid {
+		return kind, "", fmt.Errorf("not a valid %s identifier", kind)
+	}
+	return kind, value, nil
+}
+
+// doiFrom picks the first valid DOI from untyped identifiers, normalised. Failing that the first that looks like a DOI
+// is given lowercased, less any prefix, so that a DOI with an unusual registrant is not lost.
+func doiFrom(ids []string) string {
+	for _, id := range ids {
+		if doi, err := NormaliseDOI(id); err == nil {
+			return doi
+		}
+	}
+	for _, id := range ids {
+		if doi := looseDOI(id); doi != "" {
+			return doi
+		}
+	}
+	return ""
+}
+
+// looseDOI returns id as a DOI when it looks like one without being valid, lowercased as NormaliseDOI would, or ""
+func looseDOI(id string) string {
+	doi := strings.TrimSpace(id)
+	lower := strings.ToLower(doi)
+	for _, p := range identifierPrefix {
+		if p.kind == IdentifierDOI && strings.HasPrefix(lower, p.prefix) {
+			doi = strings.TrimSpace(doi[len(p.prefix):])
+			break
+		}
+	}
+	if strings.HasPrefix(doi, "10.") && strings.Contains(doi, "/") {
+		return strings.ToLower(doi)
+	}
+	return ""
+}
+
+// issnFrom returns the normalised ISSN from an identifier such as "issn:0143-7739", or "" if it is not a valid ISSN
+func issnFrom(id string) string {
+	issn, _ := NormaliseISSN(id)
+	return issn
+}
+
+// IdentifierError reports an identifier of an article that could not be normalised
+type IdentifierError struct {
+	Field string // where the identifier was found, such as "doi" or "identifiers[2]"
+	Value string
+	Err   error
+}
+
+func (e *IdentifierError) Error() string {
+	return fmt.Sprintf("invalid identifier %q in %s: %v", e.Value, e.Field, e.Err)
+}
+
+// ArticleIdentifiers holds the identifiers of an article sorted by kind and normalised, each listed once in the
+// order found, together with those that were invalid
+type ArticleIdentifiers struct {
+	DOIs     []string
+	ISSNs    []string
+	OAIs     []string
+	PMIDs    []string
+	ArXivIDs []string
+	Handles  []string
+	URLs     []string
+	Invalid  []*IdentifierError
+}
+
+func (ai *ArticleIdentifiers) add(kind, value string) {
+	var list *[]string
+	switch kind {
+	case IdentifierDOI:
+		list = &ai.DOIs
+	case IdentifierISSN:
+		list = &ai.ISSNs
+	case IdentifierOAI:
+		list = &ai.OAIs
+	case IdentifierPMID:
+		list = &ai.PMIDs
+	case IdentifierArXiv:
+		list = &ai.ArXivIDs
+	case IdentifierHandle:
+		list = &ai.Handles
+	case IdentifierURL:
+		list = &ai.URLs
+	}
+	for _, v := range *list {
+		if v == value {
+			return
+		}
+	}
+	*list = append(*list, value)
+}
+
+func (ai *ArticleIdentifiers) invalid(field, value string, err error) {
+	ai.Invalid = append(ai.Invalid, &IdentifierError{Field: field, Value: value, Err: err})
+}
+
+// CheckIdentifiers normalises and classifies the DOI, ISSN, identifiers and journal identifiers of the article.
+// Empty identifiers are ignored. Journal identifiers are expected to be ISSNs, but may be of any kind.
+func (fs *FSArticle) CheckIdentifiers() *ArticleIdentifiers {
+	ai := &ArticleIdentifiers{}
+	if strings.TrimSpace(fs.DOI) != "" {
+		if doi, err := NormaliseDOI(fs.DOI); err != nil {
+			ai.invalid("doi", fs.DOI, err)
+		} else {
+			ai.add(IdentifierDOI, doi)
+		}
+	}
+	if strings.TrimSpace(fs.ISSN) != "" {
+		if issn, err := NormaliseISSN(fs.ISSN); err != nil {
+			ai.invalid("issn", fs.ISSN, err)
+		} else {
+			ai.add(IdentifierISSN, issn)
+		}
+	}
+	for i, id := range fs.Identifiers {
+		if strings.TrimSpace(id) == "" {
+			continue
+		}
+		if kind, value, err := ClassifyIdentifier(id); err != nil {
+			ai.invalid(fmt.Sprintf("identifiers[%d]", i), id, err)
+		} else {
+			ai.add(kind, value)
+		}
+	}
+	for i, j := range fs.Journals {
+		for k, id := range j.Identifiers {
+			if strings.TrimSpace(id) == "" {
+				continue
+			}
+			field := fmt.Sprintf("journals[%d].identifiers[%d]", i, k)
+			issn, err := NormaliseISSN(id)
+			if err == nil {
+				ai.add(IdentifierISSN, issn)
+				continue
+			}
+			if err == ErrISSNCheckDigit {
+				ai.invalid(field, id, err)
+				continue
+			}
+			if kind, value, err := ClassifyIdentifier(id); err != nil {
+				ai.invalid(field, id, err)
+			} else {
+				ai.add(kind, value)
+			}
+		}
+	}
+	return ai
+}
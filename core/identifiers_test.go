@@ -0,0 +1,151 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormaliseDOI(t *testing.T) {
+	testData := []struct {
+		tag    string
+		input  string
+		exp    string
+		expErr error
+	}{
+		{tag: "bare", input: "10.1056/NEJMoa2034577", exp: "10.1056/nejmoa2034577"},
+		{tag: "prefix", input: " doi: 10.1056/abc ", exp: "10.1056/abc"},
+		{tag: "upper case prefix", input: "DOI:10.1056/abc", exp: "10.1056/abc"},
+		{tag: "info uri", input: "info:doi/10.1056/abc", exp: "10.1056/abc"},
+		{tag: "resolver", input: "https://doi.org/10.1002/(SICI)1097-4571", exp: "10.1002/(sici)1097-4571"},
+		{tag: "old resolver, escaped", input: "http://dx.doi.org/10.1000%2FABC%3C1%3E", exp: "10.1000/abc<1>"},
+		{tag: "subdivided registrant", input: "10.1000.10/123", exp: "10.1000.10/123"},
+		{tag: "no suffix", input: "10.1056/", expErr: ErrInvalidDOI},
+		{tag: "short registrant", input: "10.1/abc", expErr: ErrInvalidDOI},
+		{tag: "not a doi", input: "oai:x:1", expErr: ErrInvalidDOI},
+		{tag: "empty", input: "", expErr: ErrInvalidDOI},
+	}
+	for _, td := range testData {
+		doi, err := NormaliseDOI(td.input)
+		assert.Equal(t, td.expErr, err, td.tag)
+		assert.Equal(t, td.exp, doi, td.tag)
+	}
+}
+
+func TestDOIFrom(t *testing.T) {
+	testData := []struct {
+		tag string
+		ids []string
+		exp string
+	}{
+		{tag: "normalised", ids: []string{"oai:x:1", "https://doi.org/10.1056/ABC"}, exp: "10.1056/abc"},
+		{tag: "lowercased when it cannot be normalised", ids: []string{"oai:x:1", "doi:10.1/ABC"}, exp: "10.1/abc"},
+		{tag: "first valid wins", ids: []string{"10.1000/x", "10.1056/y"}, exp: "10.1000/x"},
+		{tag: "valid preferred to an earlier fallback", ids: []string{"10.2/x", "10.1056/Y"}, exp: "10.1056/y"},
+		{tag: "first fallback", ids: []string{"doi:abc", "10.2/X", "10.3/y"}, exp: "10.2/x"},
+		{tag: "not a doi", ids: []string{"doi:abc", "oai:x:1", ""}, exp: ""},
+	}
+	for _, td := range testData {
+		assert.Equal(t, td.exp, doiFrom(td.ids), td.tag)
+	}
+}
+
+func TestNormaliseISSN(t *testing.T) {
+	testData := []struct {
+		tag    string
+		input  string
+		exp    string
+		expErr error
+	}{
+		{tag: "hyphenated", input: "0143-7739", exp: "0143-7739"},
+		{tag: "no hyphen", input: "10778306", exp: "1077-8306"},
+		{tag: "prefix", input: "issn: 0143-7739", exp: "0143-7739"},
+		{tag: "eissn prefix", input: "eISSN:1234-5679", exp: "1234-5679"},
+		{tag: "check digit X", input: "2434-561x", exp: "2434-561X"},
+		{tag: "check digit 0", input: "0000-0000", exp: "0000-0000"},
+		{tag: "wrong check digit", input: "0143-7738", expErr: ErrISSNCheckDigit},
+		{tag: "too short", input: "0143-773", expErr: ErrInvalidISSN},
+		{tag: "letters", input: "ABCD-EFGH", expErr: ErrInvalidISSN},
+		{tag: "misplaced X", input: "X143-7739", expErr: ErrInvalidISSN},
+	}
+	for _, td := range testData {
+		issn, err := NormaliseISSN(td.input)
+		assert.Equal(t, td.expErr, err, td.tag)
+		assert.Equal(t, td.exp, issn, td.tag)
+	}
+}
+
+func TestClassifyIdentifier(t *testing.T) {
+	testData := []struct {
+		tag      string
+		input    string
+		expKind  string
+		expValue string
+		expErr   bool
+	}{
+		{tag: "doi", input: "10.1056/ABC", expKind: IdentifierDOI, expValue: "10.1056/abc"},
+		{tag: "doi url", input: "https://doi.org/10.1056/abc", expKind: IdentifierDOI, expValue: "10.1056/abc"},
+		{tag: "bad doi", input: "doi:abc", expKind: IdentifierDOI, expErr: true},
+		{tag: "issn", input: "issn:10778306", expKind: IdentifierISSN, expValue: "1077-8306"},
+		{tag: "bare issn", input: "1077-8306", expErr: true},
+		{tag: "oai", input: "oai:clok.uclan.ac.uk:14639", expKind: IdentifierOAI, expValue: "oai:clok.uclan.ac.uk:14639"},
+		{tag: "bad oai", input: "oai:14639", expKind: IdentifierOAI, expErr: true},
+		{tag: "pmid", input: "PMID: 33301246", expKind: IdentifierPMID, expValue: "33301246"},
+		{tag: "pubmed url", input: "https://pubmed.ncbi.nlm.nih.gov/33301246/", expKind: IdentifierPMID, expValue: "33301246"},
+		{tag: "bad pmid", input: "pmid:PMC123", expKind: IdentifierPMID, expErr: true},
+		{tag: "arxiv", input: "arXiv:2101.00001v2", expKind: IdentifierArXiv, expValue: "2101.00001v2"},
+		{tag: "old arxiv", input: "arxiv:math.GT/0309136", expKind: IdentifierArXiv, expValue: "math.GT/0309136"},
+		{tag: "arxiv pdf", input: "https://arxiv.org/pdf/1706.03762.pdf", expKind: IdentifierArXiv, expValue: "1706.03762"},
+		{tag: "bad arxiv", input: "arxiv:17.06", expKind: IdentifierArXiv, expErr: true},
+		{tag: "handle", input: "hdl:10068/625432", expKind: IdentifierHandle, expValue: "10068/625432"},
+		{tag: "handle url", input: "http://hdl.handle.net/2066/12345", expKind: IdentifierHandle, expValue: "2066/12345"},
+		{tag: "bad handle", input: "hdl:abc", expKind: IdentifierHandle, expErr: true},
+		{tag: "url", input: "http://clok.uclan.ac.uk/14639/", expKind: IdentifierURL, expValue: "http://clok.uclan.ac.uk/14639/"},
+		{tag: "bad url", input: "http:///path", expKind: IdentifierURL, expErr: true},
+		{tag: "unknown", input: "123456", expErr: true},
+	}
+	for _, td := range testData {
+		kind, value, err := ClassifyIdentifier(td.input)
+		assert.Equal(t, td.expKind, kind, td.tag)
+		assert.Equal(t, td.expValue, value, td.tag)
+		assert.Equal(t, td.expErr, err != nil, td.tag)
+	}
+}
+
+func TestCheckIdentifiers(t *testing.T) {
+	fs := &FSArticle{
+		DOI:  "https://doi.org/10.1056/ABC",
+		ISSN: "01437738",
+		Identifiers: []string{
+			"oai:clok.uclan.ac.uk:14639",
+			"",
+			"doi:10.1056/abc",
+			"pmid:33301246",
+			"http://clok.uclan.ac.uk/14639/",
+			"core:42",
+		},
+		Journals: []FSJournal{
+			{Identifiers: []string{"issn:0143-7739", "10778306", "1234-5678"}},
+			{Identifiers: []string{"hdl:10068/625432"}},
+		},
+	}
+	exp := &ArticleIdentifiers{
+		DOIs:    []string{"10.1056/abc"},
+		ISSNs:   []string{"0143-7739", "1077-8306"},
+		OAIs:    []string{"oai:clok.uclan.ac.uk:14639"},
+		PMIDs:   []string{"33301246"},
+		Handles: []string{"10068/625432"},
+		URLs:    []string{"http://clok.uclan.ac.uk/14639/"},
+		Invalid: []*IdentifierError{
+			{Field: "issn", Value: "01437738", Err: ErrISSNCheckDigit},
+			{Field: "identifiers[5]", Value: "core:42", Err: ErrUnknownIdentifier},
+			{Field: "journals[0].identifiers[2]", Value: "1234-5678", Err: ErrISSNCheckDigit},
+		},
+	}
+	assert.Equal(t, exp, fs.CheckIdentifiers())
+	assert.Equal(t, `invalid identifier "core:42" in identifiers[5]: unrecognised identifier`, exp.Invalid[1].Error())
+
+	ai := expFSArticle.CheckIdentifiers()
+	assert.Empty(t, ai.Invalid)
+	assert.Equal(t, []string{"1077-8306", "0143-7739"}, ai.ISSNs)
+}
@@ -0,0 +1,151 @@
+package core
+
+// The models returned by the CORE API v3. Fields CORE leaves empty are simply zero valued, and fields not listed here
+// are ignored when decoding.
+
+// Author is a named author of a work or output
+type Author struct {
+	Name string `json:"name"`
+}
+
+// Identifier is an identifier of a work together with its type, such as DOI, OAI or CORE_ID
+type Identifier struct {
+	Identifier string `json:"identifier"`
+	Type       string `json:"type"`
+}
+
+// Link is a typed link to a CORE resource, such as the download or display page of a work
+type Link struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// DataProviderRef is the short form of a data provider embedded in works and outputs
+type DataProviderRef struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	Logo string `json:"logo"`
+}
+
+// JournalRef is the short form of a journal embedded in works and outputs, the identifiers are typically ISSNs
+type JournalRef struct {
+	Title       string   `json:"title"`
+	Identifiers []string `json:"identifiers"`
+}
+
+// Reference is an entry in the reference list of a work or output
+type Reference struct {
+	ID      int64    `json:"id"`
+	Title   string   `json:"title"`
+	Authors []string `json:"authors"`
+	Date    string   `json:"date"`
+	DOI     string   `json:"doi"`
+	Raw     string   `json:"raw"`
+	Cites   []int64  `json:"cites"`
+}
+
+// Work is a scholarly work, deduplicated by CORE from the outputs of one or more data providers
+type Work struct {
+	ID                 int64             `json:"id"`
+	Title              string            `json:"title"`
+	Abstract           string            `json:"abstract"`
+	Authors            []Author          `json:"authors"`
+	Contributors       []string          `json:"contributors"`
+	DOI                string            `json:"doi"`
+	ArXivID            string            `json:"arxivId"`
+	MAGID              string            `json:"magId"`
+	PubMedID           string            `json:"pubmedId"`
+	OAIIDs             []string          `json:"oaiIds"`
+	Identifiers        []Identifier      `json:"identifiers"`
+	DocumentType       string            `json:"documentType"`
+	FieldOfStudy       string            `json:"fieldOfStudy"`
+	Language           Language          `json:"language"`
+	Publisher          string            `json:"publisher"`
+	Journals           []JournalRef      `json:"journals"`
+	DataProviders      []DataProviderRef `json:"dataProviders"`
+	Outputs            []string          `json:"outputs"` // URLs of the outputs the work was built from
+	References         []Reference       `json:"references"`
+	CitationCount      int               `json:"citationCount"`
+	DownloadURL        string            `json:"downloadUrl"`
+	SourceFullTextURLs []string          `json:"sourceFulltextUrls"`
+	FullText           string            `json:"fullText"`
+	Links              []Link            `json:"links"`
+	Tags               []string          `json:"tags"`
+	YearPublished      int               `json:"yearPublished"`
+	PublishedDate      string            `json:"publishedDate"`
+	AcceptedDate       string            `json:"acceptedDate"`
+	DepositedDate      string            `json:"depositedDate"`
+	CreatedDate        string            `json:"createdDate"`
+	UpdatedDate        string            `json:"updatedDate"`
+}
+
+// Output is a single record harvested from a data provider, one or more of which make up a Work
+type Output struct {
+	ID                 int64           `json:"id"`
+	Title              string          `json:"title"`
+	Abstract           string          `json:"abstract"`
+	Authors            []Author        `json:"authors"`
+	Contributors       []string        `json:"contributors"`
+	DOI                string          `json:"doi"`
+	ArXivID            string          `json:"arxivId"`
+	MAGID              string          `json:"magId"`
+	PubMedID           string          `json:"pubmedId"`
+	OAIIDs             []string        `json:"oaiIds"`
+	Identifiers        []Identifier    `json:"identifiers"`
+	DocumentType       string          `json:"documentType"`
+	FieldOfStudy       string          `json:"fieldOfStudy"`
+	Language           Language        `json:"language"`
+	Publisher          string          `json:"publisher"`
+	Journals           []JournalRef    `json:"journals"`
+	DataProvider       DataProviderRef `json:"dataProvider"`
+	References         []Reference     `json:"references"`
+	CitationCount      int             `json:"citationCount"`
+	DownloadURL        string          `json:"downloadUrl"`
+	SourceFullTextURLs []string        `json:"sourceFulltextUrls"`
+	FullText           string          `json:"fullText"`
+	Links              []Link          `json:"links"`
+	SetSpecs           []string        `json:"setSpecs"`
+	Tags               []string        `json:"tags"`
+	YearPublished      int             `json:"yearPublished"`
+	PublishedDate      string          `json:"publishedDate"`
+	AcceptedDate       string          `json:"acceptedDate"`
+	DepositedDate      string          `json:"depositedDate"`
+	CreatedDate        string          `json:"createdDate"`
+	UpdatedDate        string          `json:"updatedDate"`
+}
+
+// Location is where a data provider is based
+type Location struct {
+	CountryCode string  `json:"countryCode"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+}
+
+// DataProvider is a repository, journal or other source CORE harvests outputs from
+type DataProvider struct {
+	ID             int64    `json:"id"`
+	OpenDOARID     int      `json:"openDoarId"`
+	Name           string   `json:"name"`
+	Email          string   `json:"email"`
+	URI            string   `json:"uri"`
+	OAIPMHURL      string   `json:"oaiPmhUrl"`
+	HomepageURL    string   `json:"homepageUrl"`
+	Source         string   `json:"source"`
+	Software       string   `json:"software"`
+	MetadataFormat string   `json:"metadataFormat"`
+	Type           string   `json:"type"`
+	Logo           string   `json:"logo"`
+	Location       Location `json:"location"`
+	Enabled        bool     `json:"enabled"`
+	CreatedDate    string   `json:"createdDate"`
+}
+
+// Journal is a journal known to CORE, identified by its ISSNs
+type Journal struct {
+	Title       string   `json:"title"`
+	Identifiers []string `json:"identifiers"`
+	Subjects    []string `json:"subjects"`
+	Language    string   `json:"language"`
+	Publisher   string   `json:"publisher"`
+}
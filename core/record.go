@@ -0,0 +1,194 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+)
+
+// The CORE channels a Record can be converted from
+const (
+	SourceAPIv2    = "api-v2"   // Article, from Extractor.Process
+	SourceAPIv3    = "api-v3"   // Work, from Client
+	SourceFastSync = "fastsync" // FSArticle, from fast sync resource dumps
+)
+
+// Record is the normalised form of a CORE article, whichever channel it came from, so that code handling articles
+// need not care whether it was given an Article, a Work or an FSArticle. Fields a channel does not provide are left
+// empty, as noted below.
+type Record struct {
+	Source        string             `json:"source"` // the channel the record came from, SourceAPIv2, SourceAPIv3 or SourceFastSync
+	CoreID        string             `json:"coreId"`
+	Title         string             `json:"title"`
+	Abstract      string             `json:"abstract"`
+	Authors       []string           `json:"authors"`
+	Contributors  []string           `json:"contributors"`
+	DOI           string             `json:"doi"` // normalised by NormaliseDOI, or lowercased where it cannot be
+	OAI           string             `json:"oai"`
+	MAGID         string             `json:"magId"` // not provided by the v2 API
+	Identifiers   []string           `json:"identifiers"`
+	DocumentType  string             `json:"documentType"` // lower case, such as "research" or "thesis"
+	Language      Language           `json:"language"`
+	Publisher     string             `json:"publisher"`
+	Journals      []JournalRef       `json:"journals"`     // not provided by the v2 API
+	Repositories  []RecordRepository `json:"repositories"` // not provided by fast sync
+	Year          int                `json:"year"`
+	DatePublished string             `json:"datePublished"`
+	Subjects      []string           `json:"subjects"`
+	Topics        []string           `json:"topics"`
+	DownloadURL   string             `json:"downloadUrl"`
+	URLs          []string           `json:"urls"` // further full text URLs
+	FullText      string             `json:"fullText"`
+	PDFHash       string             `json:"pdfHash"`       // only provided by fast sync
+	References    []Reference        `json:"references"`    // not provided by the v2 API
+	CitationCount int                `json:"citationCount"` // not provided by the v2 API
+}
+
+// RecordRepository is the repository, or data provider, an article was harvested from
+type RecordRepository struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Record converts the article from the v2 API to the normalised form
+func (a *Article) Record() *Record {
+	r := &Record{
+		Source:        SourceAPIv2,
+		CoreID:        a.ID,
+		Title:         a.Title,
+		Abstract:      a.Description,
+		Authors:       a.Authors,
+		Contributors:  a.Contributors,
+		DOI:           doiFrom(a.Identifiers),
+		OAI:           a.OAI,
+		Identifiers:   a.Identifiers,
+		Language:      a.Language,
+		Publisher:     a.Publisher,
+		Year:          a.Year,
+		DatePublished: a.DatePublished,
+		Subjects:      a.Subjects,
+		Topics:        a.Topics,
+		DownloadURL:   a.DownloadURL,
+		URLs:          a.FullTextURLs,
+		FullText:      a.FullText,
+	}
+	if len(a.Types) > 0 {
+		r.DocumentType = strings.ToLower(a.Types[0])
+	}
+	for _, repo := range a.Repositories {
+		r.Repositories = append(r.Repositories, RecordRepository{ID: repo.ID, Name: repo.Name})
+	}
+	r.fillDownloadURL()
+	return r
+}
+
+// Record converts the fast sync article to the normalised form
+func (fs *FSArticle) Record() *Record {
+	r := &Record{
+		Source:        SourceFastSync,
+		CoreID:        fs.CoreID,
+		Title:         fs.Title,
+		Abstract:      fs.Abstract,
+		Authors:       fs.Authors,
+		Contributors:  fs.Contributors,
+		DOI:           doiFrom(append([]string{fs.DOI}, fs.Identifiers...)),
+		OAI:           fs.OAIID,
+		MAGID:         fs.MAGID,
+		Identifiers:   fs.Identifiers,
+		DocumentType:  strings.ToLower(fs.Enrichments.DocType.Type),
+		Language:      Language{Code: fs.Language.Code, ID: fs.Language.ID, Name: fs.Language.Name},
+		Publisher:     fs.Publisher,
+		Year:          fs.Year,
+		DatePublished: fs.DatePublished,
+		Subjects:      fs.Subjects,
+		Topics:        fs.Topics,
+		DownloadURL:   fs.DownloadURL,
+		URLs:          fs.URLs,
+		FullText:      fs.FullText,
+		PDFHash:       fs.PDFHashValue,
+		CitationCount: fs.Enrichments.CitationCount,
+	}
+	for _, j := range fs.Journals {
+		r.Journals = append(r.Journals, JournalRef{Title: j.Title, Identifiers: j.Identifiers})
+	}
+	// the article level ISSN is kept with the journals, where the other channels give it
+	if issn := issnFrom(fs.ISSN); issn != "" && !r.hasISSN(issn) {
+		r.Journals = append(r.Journals, JournalRef{Identifiers: []string{"issn:" + issn}})
+	}
+	for _, ref := range fs.Enrichments.References {
+		rr := Reference{ID: int64(ref.ID), Title: ref.Title, Authors: ref.Authors, Date: ref.Date, DOI: ref.DOI, Raw: ref.Raw}
+		for _, c := range ref.Cites {
+			rr.Cites = append(rr.Cites, int64(c))
+		}
+		r.References = append(r.References, rr)
+	}
+	r.fillDownloadURL()
+	return r
+}
+
+// Record converts the work from the v3 API to the normalised form
+func (w *Work) Record() *Record {
+	r := &Record{
+		Source:        SourceAPIv3,
+		CoreID:        strconv.FormatInt(w.ID, 10),
+		Title:         w.Title,
+		Abstract:      w.Abstract,
+		Contributors:  w.Contributors,
+		DOI:           doiFrom([]string{w.DOI}),
+		MAGID:         w.MAGID,
+		DocumentType:  strings.ToLower(w.DocumentType),
+		Language:      w.Language,
+		Publisher:     w.Publisher,
+		Journals:      w.Journals,
+		Year:          w.YearPublished,
+		DatePublished: w.PublishedDate,
+		Subjects:      w.Tags,
+		DownloadURL:   w.DownloadURL,
+		URLs:          w.SourceFullTextURLs,
+		FullText:      w.FullText,
+		References:    w.References,
+		CitationCount: w.CitationCount,
+	}
+	if w.ID == 0 {
+		r.CoreID = ""
+	}
+	if w.FieldOfStudy != "" {
+		r.Topics = []string{w.FieldOfStudy}
+	}
+	for _, a := range w.Authors {
+		r.Authors = append(r.Authors, a.Name)
+	}
+	if len(w.OAIIDs) > 0 {
+		r.OAI = w.OAIIDs[0]
+	}
+	for _, id := range w.Identifiers {
+		r.Identifiers = append(r.Identifiers, id.Identifier)
+	}
+	for _, dp := range w.DataProviders {
+		r.Repositories = append(r.Repositories, RecordRepository{ID: strconv.FormatInt(dp.ID, 10), Name: dp.Name})
+	}
+	r.fillDownloadURL()
+	return r
+}
+
+// fillDownloadURL falls back to the first of the full text URLs where no download URL is given
+func (r *Record) fillDownloadURL() {
+	if r.DownloadURL == "" && len(r.URLs) > 0 {
+		r.DownloadURL = r.URLs[0]
+	}
+}
+
+// hasISSN reports whether any journal of the record has the ISSN
+func (r *Record) hasISSN(issn string) bool {
+	want := issnFrom(issn)
+	if want == "" {
+		return false
+	}
+	for _, j := range r.Journals {
+		for _, id := range j.Identifiers {
+			if issnFrom(id) == want {
+				return true
+			}
+		}
+	}
+	return false
+}
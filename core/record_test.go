@@ -0,0 +1,112 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArticleRecord(t *testing.T) {
+	a := expArticleWrapper.Data
+	exp := &Record{
+		Source:        SourceAPIv2,
+		CoreID:        "123456",
+		Title:         "This is a long and complicated article",
+		Abstract:      a.Description,
+		Authors:       []string{"Davies, Nathan"},
+		Contributors:  []string{"Davies, Sarah", "Smith, John", "Brown, Emma"},
+		OAI:           "oai:dspace.ac:123456",
+		Identifiers:   []string{"oai:dspace.ac:123456"},
+		Language:      Language{Code: "et", ID: 11, Name: "Estonian"},
+		Publisher:     "Publishing House",
+		Repositories:  []RecordRepository{{ID: "123", Name: "DSpace at Publishing House"}},
+		Year:          2012,
+		DatePublished: "2012",
+		Subjects:      []string{"Thesis"},
+		Topics:        []string{"article", "complexity", "media"},
+		DownloadURL:   "https://example.com/download/pdf/123456.pdf",
+		URLs:          []string{"http://example.com/download/pdf/123456.pdf", "http://original.example.com/download/pdf/123456.pdf"},
+		FullText:      a.FullText,
+	}
+	assert.Equal(t, exp, a.Record())
+}
+
+func TestFSArticleRecord(t *testing.T) {
+	r := expFSArticle.Record()
+	assert.Equal(t, SourceFastSync, r.Source)
+	assert.Equal(t, "42138760", r.CoreID)
+	assert.Equal(t, "oai:clok.uclan.ac.uk:14639", r.OAI)
+	assert.Equal(t, "2144009174", r.MAGID)
+	assert.Equal(t, "research", r.DocumentType)
+	assert.Equal(t, Language{Code: "en", ID: 9, Name: "English"}, r.Language)
+	assert.Equal(t, "97a86466f1afdd62cd885ef03fc75c483bef767d", r.PDFHash)
+	assert.Equal(t, 2, r.CitationCount)
+	assert.Equal(t, []JournalRef{
+		{Identifiers: []string{"issn:0143-7739", "0143-7739"}},
+		{Identifiers: []string{"issn:1077-8306"}},
+	}, r.Journals)
+	assert.Len(t, r.References, 2)
+	assert.Equal(t, Reference{ID: 36385704, Title: expFSArticle.Enrichments.References[0].Title, Authors: []string{},
+		Date: "1993", DOI: "10.1056/test-doi", Raw: expFSArticle.Enrichments.References[0].Raw}, r.References[0])
+}
+
+func TestWorkRecord(t *testing.T) {
+	w := &Work{
+		ID:                 42,
+		Title:              "T",
+		Authors:            []Author{{Name: "Wood, Marsha"}, {Name: "Barter, Christine"}},
+		DOI:                "10.1/x",
+		OAIIDs:             []string{"oai:x:1", "oai:y:1"},
+		Identifiers:        []Identifier{{Identifier: "10.1/x", Type: "DOI"}, {Identifier: "oai:x:1", Type: "OAI_ID"}},
+		DocumentType:       "Thesis",
+		FieldOfStudy:       "sociology",
+		DataProviders:      []DataProviderRef{{ID: 7, Name: "Repo"}},
+		References:         []Reference{{ID: 1, Cites: []int64{9}}},
+		YearPublished:      2019,
+		Tags:               []string{"ref"},
+		SourceFullTextURLs: []string{"http://x/1"},
+	}
+	exp := &Record{
+		Source:       SourceAPIv3,
+		CoreID:       "42",
+		Title:        "T",
+		Authors:      []string{"Wood, Marsha", "Barter, Christine"},
+		DOI:          "10.1/x",
+		OAI:          "oai:x:1",
+		Identifiers:  []string{"10.1/x", "oai:x:1"},
+		DocumentType: "thesis",
+		Repositories: []RecordRepository{{ID: "7", Name: "Repo"}},
+		Year:         2019,
+		Subjects:     []string{"ref"},
+		Topics:       []string{"sociology"},
+		DownloadURL:  "http://x/1",
+		URLs:         []string{"http://x/1"},
+		References:   []Reference{{ID: 1, Cites: []int64{9}}},
+	}
+	assert.Equal(t, exp, w.Record())
+	assert.Equal(t, "", (&Work{}).Record().CoreID)
+}
+
+func TestRecordFallbacks(t *testing.T) {
+	testData := []struct {
+		tag    string
+		record *Record
+		expDOI string
+		expURL string
+	}{
+		{tag: "fast sync doi field", record: (&FSArticle{DOI: "10.1/a", Identifiers: []string{"doi:10.1/b"}}).Record(), expDOI: "10.1/a"},
+		{tag: "fast sync doi identifier", record: (&FSArticle{Identifiers: []string{"oai:x", "doi:10.1/b"}}).Record(), expDOI: "10.1/b"},
+		{tag: "article doi identifier", record: (&Article{Identifiers: []string{"https://doi.org/10.1/c"}}).Record(), expDOI: "10.1/c"},
+		{tag: "download url", record: (&FSArticle{DownloadURL: "http://x/a.pdf", URLs: []string{"http://x/a"}}).Record(), expURL: "http://x/a.pdf"},
+		{tag: "first full text url", record: (&FSArticle{URLs: []string{"http://x/a", "http://x/b"}}).Record(), expURL: "http://x/a"},
+	}
+	for _, td := range testData {
+		assert.Equal(t, td.expDOI, td.record.DOI, td.tag)
+		assert.Equal(t, td.expURL, td.record.DownloadURL, td.tag)
+	}
+}
+
+func TestRecordISSN(t *testing.T) {
+	r := (&FSArticle{ISSN: "0143-7739", Journals: []FSJournal{{Title: "J", Identifiers: []string{"issn:0143-7739"}}}}).Record()
+	assert.Equal(t, []JournalRef{{Title: "J", Identifiers: []string{"issn:0143-7739"}}}, r.Journals, "not repeated")
+}
@@ -0,0 +1,200 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultPageSize is the number of results requested in each page of a search when Search.PageSize is not set
+const DefaultPageSize = 100
+
+// Search describes a search of CORE works. Query is written in the CORE query language and the filters are added to
+// it, every filter given must match. Only the Query is required.
+type Search struct {
+	Query         string
+	YearFrom      int      // optional, the earliest year published
+	YearTo        int      // optional, the latest year published
+	Languages     []string // optional, ISO 639-1 codes such as "en", a work in any of them matches
+	DocumentTypes []string // optional, such as "research" or "thesis", a work of any of them matches
+	DataProviders []string // optional, the CORE IDs of the repositories the work must come from, any of them matches
+	PageSize      int      // optional, DefaultPageSize is used if 0
+	MaxResults    int      // optional, the iterator stops after this many results, 0 for no limit
+	// Scroll pages through the results with a scroll ID rather than an offset, which CORE requires to read beyond
+	// the first 10,000 results
+	Scroll bool
+}
+
+// q builds the full CORE query from the Query and filters
+func (s Search) q() string {
+	var clauses []string
+	if q := strings.TrimSpace(s.Query); q != "" {
+		clauses = append(clauses, "("+q+")")
+	}
+	if s.YearFrom > 0 {
+		clauses = append(clauses, fmt.Sprintf("yearPublished>=%d", s.YearFrom))
+	}
+	if s.YearTo > 0 {
+		clauses = append(clauses, fmt.Sprintf("yearPublished<=%d", s.YearTo))
+	}
+	for _, f := range []struct {
+		field  string
+		values []string
+	}{
+		{"language.code", s.Languages},
+		{"documentType", s.DocumentTypes},
+		{"dataProviders.id", s.DataProviders},
+	} {
+		if clause := anyOf(f.field, f.values); clause != "" {
+			clauses = append(clauses, clause)
+		}
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+// anyOf builds a clause matching the field against any of the values
+func anyOf(field string, values []string) string {
+	var terms []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			terms = append(terms, field+":"+strconv.Quote(v))
+		}
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	return "(" + strings.Join(terms, " OR ") + ")"
+}
+
+// searchResponse is a single page of search results
+type searchResponse struct {
+	TotalHits int    `json:"totalHits"`
+	Limit     int    `json:"limit"`
+	Offset    int    `json:"offset"`
+	ScrollID  string `json:"scrollId"`
+	Results   []Work `json:"results"`
+}
+
+// WorkIterator steps through the results of a search, requesting further pages from CORE as they are needed.
+// Use it in the same way as a bufio.Scanner:
+//
+//	it := client.SearchWorks(core.Search{Query: "climate", YearFrom: 2015})
+//	for it.Next() {
+//		work := it.Work()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type WorkIterator struct {
+	client   *Client
+	search   Search
+	page     []Work
+	current  *Work
+	offset   int // the offset of the next page
+	returned int
+	total    int
+	scrollID string
+	started  bool
+	done     bool
+	err      error
+}
+
+// SearchWorks returns an iterator over the works matching the search. No request is made until Next is called.
+func (c *Client) SearchWorks(s Search) *WorkIterator {
+	if s.PageSize <= 0 {
+		s.PageSize = DefaultPageSize
+	}
+	return &WorkIterator{client: c, search: s}
+}
+
+// Next advances to the next work, returning false once the results are exhausted or a request fails
+func (it *WorkIterator) Next() bool {
+	if it.done {
+		return false
+	}
+	if it.search.MaxResults > 0 && it.returned >= it.search.MaxResults {
+		it.finish()
+		return false
+	}
+	if len(it.page) == 0 {
+		if it.started && it.exhausted() {
+			it.finish()
+			return false
+		}
+		if err := it.fetch(); err != nil {
+			it.err = err
+			it.finish()
+			return false
+		}
+		if len(it.page) == 0 {
+			it.finish()
+			return false
+		}
+	}
+	it.current = &it.page[0]
+	it.page = it.page[1:]
+	it.returned++
+	return true
+}
+
+// Work is the current work, valid after Next has returned true
+func (it *WorkIterator) Work() *Work {
+	return it.current
+}
+
+// Total is the number of works CORE reported as matching, known once Next has been called
+func (it *WorkIterator) Total() int {
+	return it.total
+}
+
+// Err is the error that stopped the iteration, nil if the results were simply exhausted
+func (it *WorkIterator) Err() error {
+	return it.err
+}
+
+// exhausted reports whether every result has been requested, scrolling only ends on an empty page
+func (it *WorkIterator) exhausted() bool {
+	return !it.search.Scroll && it.offset >= it.total
+}
+
+func (it *WorkIterator) finish() {
+	it.done = true
+	it.current = nil
+	it.page = nil
+}
+
+// fetch requests the next page of results
+func (it *WorkIterator) fetch() error {
+	limit := it.search.PageSize
+	if max := it.search.MaxResults; max > 0 && max-it.returned < limit {
+		limit = max - it.returned
+	}
+	query := url.Values{}
+	query.Set("q", it.search.q())
+	query.Set("limit", strconv.Itoa(limit))
+	switch {
+	case !it.search.Scroll:
+		query.Set("offset", strconv.Itoa(it.offset))
+	case it.scrollID == "":
+		query.Set("scroll", "true")
+	default:
+		query.Set("scrollId", it.scrollID)
+	}
+	res := &searchResponse{}
+	if err := it.client.get("/search/works", query, res); err != nil {
+		return err
+	}
+	it.started = true
+	it.total = res.TotalHits
+	it.offset += len(res.Results)
+	if res.ScrollID != "" {
+		it.scrollID = res.ScrollID
+	}
+	it.page = res.Results
+	return nil
+}
@@ -0,0 +1,218 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchQuery(t *testing.T) {
+	testData := []struct {
+		tag    string
+		search Search
+		exp    string
+	}{
+		{tag: "query only", search: Search{Query: "climate change"}, exp: "(climate change)"},
+		{tag: "years", search: Search{Query: "climate", YearFrom: 2010, YearTo: 2015},
+			exp: "(climate) AND yearPublished>=2010 AND yearPublished<=2015"},
+		{tag: "single values", search: Search{Query: "climate", Languages: []string{"en"}, DocumentTypes: []string{"research"}},
+			exp: `(climate) AND language.code:"en" AND documentType:"research"`},
+		{tag: "several values", search: Search{Query: "climate", DataProviders: []string{"3", " ", "12"}},
+			exp: `(climate) AND (dataProviders.id:"3" OR dataProviders.id:"12")`},
+		{tag: "filters only", search: Search{YearFrom: 2020}, exp: "yearPublished>=2020"},
+	}
+	for _, td := range testData {
+		assert.Equal(t, td.exp, td.search.q(), td.tag)
+	}
+}
+
+func TestSearchWorksOffset(t *testing.T) {
+	fs := &fakeSearch{total: 5}
+	server := httptest.NewServer(fs)
+	defer server.Close()
+	c := &Client{BaseURL: server.URL}
+
+	it := c.SearchWorks(Search{Query: "climate", PageSize: 2})
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, collectIDs(it))
+	require.Nil(t, it.Err())
+	assert.Equal(t, 5, it.Total())
+	assert.Equal(t, []string{"offset=0", "offset=2", "offset=4"}, fs.pages)
+	assert.False(t, it.Next(), "stays exhausted")
+}
+
+func TestSearchWorksScroll(t *testing.T) {
+	fs := &fakeSearch{total: 5}
+	server := httptest.NewServer(fs)
+	defer server.Close()
+	c := &Client{BaseURL: server.URL}
+
+	it := c.SearchWorks(Search{Query: "climate", PageSize: 2, Scroll: true})
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, collectIDs(it))
+	require.Nil(t, it.Err())
+	// scrolling ends on the first empty page
+	assert.Equal(t, []string{"scroll", "scrollId=2", "scrollId=4", "scrollId=5"}, fs.pages)
+}
+
+func TestSearchWorksMaxResults(t *testing.T) {
+	fs := &fakeSearch{total: 50}
+	server := httptest.NewServer(fs)
+	defer server.Close()
+	c := &Client{BaseURL: server.URL}
+
+	it := c.SearchWorks(Search{Query: "climate", PageSize: 2, MaxResults: 3})
+	assert.Equal(t, []int64{1, 2, 3}, collectIDs(it))
+	assert.Equal(t, []string{"offset=0", "offset=2"}, fs.pages)
+	assert.Equal(t, []int{2, 1}, fs.limits, "the last page only asks for what is needed")
+}
+
+func TestSearchWorksError(t *testing.T) {
+	fs := &fakeSearch{total: 5, failAt: 2}
+	server := httptest.NewServer(fs)
+	defer server.Close()
+	c := &Client{BaseURL: server.URL}
+
+	it := c.SearchWorks(Search{Query: "climate", PageSize: 2})
+	assert.Equal(t, []int64{1, 2}, collectIDs(it))
+	require.NotNil(t, it.Err())
+	assert.Equal(t, http.StatusInternalServerError, it.Err().(*APIError).StatusCode)
+	assert.Nil(t, it.Work())
+}
+
+func TestSearchWorksRateLimited(t *testing.T) {
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	fs := &fakeSearch{total: 4, tooMany: 1, remaining: map[int]string{0: "0"}}
+	server := httptest.NewServer(fs)
+	defer server.Close()
+	var slept []time.Duration
+	c := &Client{
+		BaseURL:    server.URL,
+		MaxRetries: DefaultMaxRetries,
+		now:        func() time.Time { return now },
+		sleep: func(d time.Duration) {
+			slept = append(slept, d)
+			now = now.Add(d)
+		},
+	}
+
+	it := c.SearchWorks(Search{Query: "climate", PageSize: 2})
+	assert.Equal(t, []int64{1, 2, 3, 4}, collectIDs(it))
+	require.Nil(t, it.Err())
+	// the first page used up the allowance, so the next waits; it is then refused once with a Retry-After
+	assert.Equal(t, []time.Duration{10 * time.Second, 5 * time.Second}, slept)
+	assert.Equal(t, []string{"offset=0", "offset=2", "offset=2"}, fs.pages)
+}
+
+func TestClientRetriesExhausted(t *testing.T) {
+	fs := &fakeSearch{total: 4, tooMany: 10}
+	server := httptest.NewServer(fs)
+	defer server.Close()
+	var slept []time.Duration
+	c := &Client{BaseURL: server.URL, MaxRetries: 2, sleep: func(d time.Duration) { slept = append(slept, d) }}
+
+	it := c.SearchWorks(Search{Query: "climate", PageSize: 2})
+	assert.Equal(t, []int64{1, 2}, collectIDs(it))
+	require.NotNil(t, it.Err())
+	assert.Equal(t, http.StatusTooManyRequests, it.Err().(*APIError).StatusCode)
+	assert.Equal(t, []string{"offset=0", "offset=2", "offset=2", "offset=2"}, fs.pages, "the first attempt and two retries")
+	assert.Len(t, slept, 2, "a wait before each retry")
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	testData := []struct {
+		tag    string
+		header http.Header
+		exp    time.Time
+		ok     bool
+	}{
+		{tag: "seconds", header: http.Header{"X-Ratelimit-Retry-After": {"30"}}, exp: now.Add(30 * time.Second), ok: true},
+		{tag: "rfc3339", header: http.Header{"X-Ratelimit-Retry-After": {"2019-01-01T00:01:00+00:00"}},
+			exp: now.Add(time.Minute), ok: true},
+		{tag: "standard header", header: http.Header{"Retry-After": {"Tue, 01 Jan 2019 00:02:00 GMT"}},
+			exp: now.Add(2 * time.Minute), ok: true},
+		{tag: "unparseable", header: http.Header{"Retry-After": {"soon"}}},
+		{tag: "absent", header: http.Header{}},
+	}
+	for _, td := range testData {
+		got, ok := retryAfter(td.header, now)
+		assert.Equal(t, td.ok, ok, td.tag)
+		assert.True(t, td.exp.Equal(got), td.tag)
+	}
+}
+
+//
+// Test Data
+//
+
+func collectIDs(it *WorkIterator) []int64 {
+	var ids []int64
+	for it.Next() {
+		ids = append(ids, it.Work().ID)
+	}
+	return ids
+}
+
+// fakeSearch serves works numbered 1 to total, paging by offset or by a scroll ID holding the last ID returned
+type fakeSearch struct {
+	total     int
+	failAt    int            // the offset at which to return a 500, if non zero
+	tooMany   int            // the number of requests to refuse with 429 after the first
+	remaining map[int]string // the X-RateLimit-Remaining header to send for each request number
+	mu        sync.Mutex
+	requests  int
+	pages     []string
+	limits    []int
+}
+
+func (fs *fakeSearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	q := r.URL.Query()
+	if r.URL.Path != "/search/works" || q.Get("q") != "(climate)" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	n := fs.requests
+	fs.requests++
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	fs.limits = append(fs.limits, limit)
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	switch {
+	case q.Get("scroll") == "true":
+		fs.pages = append(fs.pages, "scroll")
+	case q.Get("scrollId") != "":
+		fs.pages = append(fs.pages, "scrollId="+q.Get("scrollId"))
+		offset, _ = strconv.Atoi(q.Get("scrollId"))
+	default:
+		fs.pages = append(fs.pages, "offset="+q.Get("offset"))
+	}
+	if v, ok := fs.remaining[n]; ok {
+		w.Header().Set(headerRateLimitRemaining, v)
+		w.Header().Set(headerRateLimitRetryAfter, "10")
+	}
+	if n > 0 && fs.tooMany > 0 {
+		fs.tooMany--
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+	if fs.failAt > 0 && offset == fs.failAt {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	res := searchResponse{TotalHits: fs.total, Limit: limit, Offset: offset, Results: []Work{}}
+	for id := offset + 1; id <= fs.total && len(res.Results) < limit; id++ {
+		res.Results = append(res.Results, Work{ID: int64(id)})
+	}
+	if q.Get("scroll") == "true" || q.Get("scrollId") != "" {
+		res.ScrollID = strconv.Itoa(offset + len(res.Results))
+	}
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,35 @@
+package destination
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned when a key is not held by the Destination
+var ErrNotFound = errors.New("not found in destination")
+
+// Object describes a resource held by a Destination
+type Object struct {
+	Key          string
+	Length       int64
+	Hash         string // in the algorithm:digest form of the rs:md hash attribute, only set when requested
+	LastModified time.Time
+}
+
+// Destination is where a sync engine writes the content it retrieves
+type Destination interface {
+	// Put stores the content read from r under key, replacing anything already there. A reader of the
+	// Destination must never see partially written content.
+	Put(key string, r io.Reader) error
+	// Get returns the content held under key, or ErrNotFound. The caller must close the returned reader.
+	Get(key string) (io.ReadCloser, error)
+	// Delete removes key, it is not an error if the key does not exist
+	Delete(key string) error
+	// Stat describes the object held under key, or returns ErrNotFound. If algorithm is set, as one of the rs:md
+	// hash algorithms such as "md5" or "sha-256", the Hash of the object is included.
+	Stat(key, algorithm string) (*Object, error)
+	// List calls fn for every object with a key starting with prefix. If fn returns an error the listing stops
+	// and that error is returned.
+	List(prefix string, fn func(Object) error) error
+}
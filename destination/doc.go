@@ -0,0 +1,7 @@
+// package destination defines where synced content is written. A Destination stores resources under slash
+// separated keys, such as those produced by mirror.LocalPath, and can report the hash of what it holds so unchanged
+// resources are not written again.
+//
+// Local stores resources on the local file system, S3 stores them in any S3 compatible object store.
+
+package destination
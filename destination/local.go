@@ -0,0 +1,144 @@
+package destination
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+// tmpPrefix marks the temporary files written by Put, these are never listed
+const tmpPrefix = ".tmp-"
+
+// Local is a Destination storing content in a directory on the local file system, each key being a path
+// relative to Dir.
+type Local struct {
+	Dir string
+}
+
+// NewLocal is the simplest way to instantiate a ready to use Local destination
+func NewLocal(dir string) *Local {
+	return &Local{
+		Dir: dir,
+	}
+}
+
+// Put writes to a temporary file alongside the destination and renames it into place, so a reader never sees
+// a partially written file
+func (l *Local) Put(key string, r io.Reader) error {
+	dest := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(dest), tmpPrefix+filepath.Base(dest))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// Get opens the file held under key
+func (l *Local) Get(key string) (io.ReadCloser, error) {
+	f, err := os.Open(l.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete removes the file held under key
+func (l *Local) Delete(key string) error {
+	err := os.Remove(l.path(key))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Stat describes the file held under key, reading the file to compute the hash if an algorithm is given
+func (l *Local) Stat(key, algorithm string) (*Object, error) {
+	info, err := os.Stat(l.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%q is not a regular file", key)
+	}
+	obj := &Object{
+		Key:          cleanKey(key),
+		Length:       info.Size(),
+		LastModified: info.ModTime(),
+	}
+	if algorithm == "" {
+		return obj, nil
+	}
+	f, err := os.Open(l.path(key))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if obj.Hash, err = resourcesync.ComputeHash(f, algorithm); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// List walks Dir calling fn for each file with a key starting with prefix, in lexical order
+func (l *Local) List(prefix string, fn func(Object) error) error {
+	err := filepath.Walk(l.Dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == l.Dir {
+				return nil
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() || strings.HasPrefix(info.Name(), tmpPrefix) {
+			return nil
+		}
+		rel, err := filepath.Rel(l.Dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		return fn(Object{
+			Key:          key,
+			Length:       info.Size(),
+			LastModified: info.ModTime(),
+		})
+	})
+	return err
+}
+
+// path maps the key to a file below Dir, keys are cleaned so they can never escape Dir
+func (l *Local) path(key string) string {
+	return filepath.Join(l.Dir, filepath.FromSlash(cleanKey(key)))
+}
+
+// cleanKey normalises a key to a relative slash separated path without any parent references
+func cleanKey(key string) string {
+	return strings.TrimPrefix(path.Clean("/"+key), "/")
+}
@@ -0,0 +1,110 @@
+package destination
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rs-destination")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	testDestination(t, NewLocal(dir))
+
+	// keys can not escape the directory
+	l := NewLocal(dir)
+	require.Nil(t, l.Put("../../escape.txt", strings.NewReader("contained")))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "escape.txt"))
+	require.Nil(t, err)
+	assert.Equal(t, "contained", string(data))
+}
+
+func TestLocalListMissingDir(t *testing.T) {
+	l := NewLocal(filepath.Join(os.TempDir(), "rs-destination-does-not-exist"))
+	called := false
+	assert.Nil(t, l.List("", func(Object) error {
+		called = true
+		return nil
+	}))
+	assert.False(t, called)
+}
+
+// testDestination exercises the behaviour every Destination must share
+func testDestination(t *testing.T, d Destination) {
+	_, err := d.Get("example.com/missing.txt")
+	assert.Equal(t, ErrNotFound, err)
+	_, err = d.Stat("example.com/missing.txt", "md5")
+	assert.Equal(t, ErrNotFound, err)
+	assert.Nil(t, d.Delete("example.com/missing.txt"))
+
+	for key, content := range testContent {
+		require.Nil(t, d.Put(key, strings.NewReader(content)), key)
+	}
+	// replacing content
+	require.Nil(t, d.Put("example.com/a.txt", strings.NewReader(testContentA)))
+
+	rc, err := d.Get("example.com/a.txt")
+	require.Nil(t, err)
+	data, err := ioutil.ReadAll(rc)
+	rc.Close()
+	require.Nil(t, err)
+	assert.Equal(t, testContentA, string(data))
+
+	testData := []struct {
+		tag       string
+		algorithm string
+		expHash   string
+	}{
+		{tag: "no hash", algorithm: "", expHash: ""},
+		{tag: "md5", algorithm: "md5", expHash: "md5:9e107d9d372bb6826bd81d3542a419d6"},
+		{tag: "sha-1", algorithm: "sha-1", expHash: "sha-1:2fd4e1c67a2d28fced849ee1bb76e7391b93eb12"},
+		{tag: "sha-256", algorithm: "sha-256", expHash: "sha-256:d7a8fbb307d7809469ca9abcb0082e4f8d5651e46d3cdb762d02d0bf37c9e592"},
+	}
+	for _, td := range testData {
+		obj, err := d.Stat("example.com/a.txt", td.algorithm)
+		require.Nil(t, err, td.tag)
+		assert.Equal(t, "example.com/a.txt", obj.Key, td.tag)
+		assert.Equal(t, int64(len(testContentA)), obj.Length, td.tag)
+		assert.Equal(t, td.expHash, obj.Hash, td.tag)
+		assert.False(t, obj.LastModified.IsZero(), td.tag)
+	}
+
+	var keys []string
+	require.Nil(t, d.List("example.com/", func(obj Object) error {
+		keys = append(keys, obj.Key)
+		return nil
+	}))
+	assert.Equal(t, []string{"example.com/a.txt", "example.com/b/c.txt", "example.com/b/d.txt"}, keys)
+
+	keys = nil
+	require.Nil(t, d.List("example.com/b/", func(obj Object) error {
+		keys = append(keys, obj.Key)
+		return nil
+	}))
+	assert.Equal(t, []string{"example.com/b/c.txt", "example.com/b/d.txt"}, keys)
+
+	require.Nil(t, d.Delete("example.com/a.txt"))
+	_, err = d.Stat("example.com/a.txt", "")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+//
+// Test Data
+//
+
+// testContentA is the classic test vector, with well known hashes
+const testContentA = "The quick brown fox jumps over the lazy dog"
+
+var testContent = map[string]string{
+	"example.com/a.txt":   "to be replaced",
+	"example.com/b/c.txt": "c",
+	"example.com/b/d.txt": "d",
+	"other.org/e.txt":     "e",
+}
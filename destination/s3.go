@@ -0,0 +1,342 @@
+package destination
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+// The S3 object metadata used to record the hashes of the content, computed as it is Put
+const hashMetaPrefix = "X-Amz-Meta-Rs-"
+
+// putHashes are recorded for every object written, covering every algorithm PreferredHash can choose
+var putHashes = []string{"md5", "sha-1", "sha-256"}
+
+// amzDateFormat is the timestamp format used in AWS Signature Version 4
+const amzDateFormat = "20060102T150405Z"
+
+// S3 is a Destination storing content in a bucket of an S3 compatible object store, such as AWS S3 or MinIO.
+// Requests use path style addressing, Endpoint/Bucket/key, and are signed with AWS Signature Version 4.
+type S3 struct {
+	Endpoint  string // such as https://s3.eu-west-1.amazonaws.com or http://localhost:9000
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	Prefix    string // optional, prepended to every key
+	Client    *http.Client
+	now       func() time.Time
+}
+
+// NewS3 is the simplest way to instantiate a ready to use S3 destination
+func NewS3(endpoint, bucket, region, accessKey, secretKey string) *S3 {
+	return &S3{
+		Endpoint:  strings.TrimSuffix(endpoint, "/"),
+		Bucket:    bucket,
+		Region:    region,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		Client:    http.DefaultClient,
+	}
+}
+
+// Put uploads the content as a single object. The content is read into memory first so that it can be signed and
+// so that its hashes can be stored alongside it as object metadata, letting Stat answer without a download.
+func (s *S3) Put(key string, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	for _, alg := range putHashes {
+		h, err := resourcesync.ComputeHash(bytes.NewReader(data), alg)
+		if err != nil {
+			return err
+		}
+		header.Set(hashMetaPrefix+alg, h)
+	}
+	resp, err := s.do(http.MethodPut, s.objectPath(key), nil, header, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return s.checkResponse(resp, key)
+}
+
+// Get downloads the object held under key
+func (s *S3) Get(key string) (io.ReadCloser, error) {
+	resp, err := s.do(http.MethodGet, s.objectPath(key), nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkResponse(resp, key); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes the object held under key, S3 does not report an error for a missing key
+func (s *S3) Delete(key string) error {
+	resp, err := s.do(http.MethodDelete, s.objectPath(key), nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	err = s.checkResponse(resp, key)
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// Stat describes the object held under key. The hash is taken from the metadata recorded by Put, or from the ETag
+// for md5 of objects uploaded in a single part; failing that the object is downloaded to compute it.
+func (s *S3) Stat(key, algorithm string) (*Object, error) {
+	resp, err := s.do(http.MethodHead, s.objectPath(key), nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if err := s.checkResponse(resp, key); err != nil {
+		return nil, err
+	}
+	obj := &Object{
+		Key:    cleanKey(key),
+		Length: resp.ContentLength,
+	}
+	if lm, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		obj.LastModified = lm
+	}
+	if algorithm == "" {
+		return obj, nil
+	}
+	algorithm = strings.ToLower(algorithm)
+	if h := resp.Header.Get(hashMetaPrefix + algorithm); h != "" {
+		obj.Hash = h
+		return obj, nil
+	}
+	etag := strings.Trim(resp.Header.Get("ETag"), `"`)
+	if algorithm == "md5" && etag != "" && !strings.Contains(etag, "-") {
+		obj.Hash = "md5:" + strings.ToLower(etag)
+		return obj, nil
+	}
+	body, err := s.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	if obj.Hash, err = resourcesync.ComputeHash(body, algorithm); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// listBucketResult is the part of the ListObjectsV2 response used by List
+type listBucketResult struct {
+	Contents []struct {
+		Key          string `xml:"Key"`
+		Size         int64  `xml:"Size"`
+		LastModified string `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List pages through the bucket with ListObjectsV2, calling fn for every object with a key starting with prefix
+func (s *S3) List(prefix string, fn func(Object) error) error {
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", s.Prefix+prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(http.MethodGet, "/"+s.Bucket+"/", query, nil, nil)
+		if err != nil {
+			return err
+		}
+		if err := s.checkResponse(resp, prefix); err != nil {
+			resp.Body.Close()
+			return err
+		}
+		result := listBucketResult{}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("invalid list response: %v", err)
+		}
+		for _, c := range result.Contents {
+			obj := Object{
+				Key:    strings.TrimPrefix(c.Key, s.Prefix),
+				Length: c.Size,
+			}
+			if lm, err := time.Parse(time.RFC3339Nano, c.LastModified); err == nil {
+				obj.LastModified = lm
+			}
+			if err := fn(obj); err != nil {
+				return err
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// objectPath is the request path of the object held under key
+func (s *S3) objectPath(key string) string {
+	return "/" + s.Bucket + "/" + s.Prefix + cleanKey(key)
+}
+
+func (s *S3) checkResponse(resp *http.Response, key string) error {
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode >= 300:
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%d: request for %q failed: %s", resp.StatusCode, key, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// do sends a signed request to the object store
+func (s *S3) do(method, p string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	u, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %v", s.Endpoint, err)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + p
+	u.RawPath = escapeURIPath(u.Path)
+	u.RawQuery = canonicalQuery(query)
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	if body == nil {
+		req.Body = nil
+		req.ContentLength = 0
+	}
+	s.sign(req, body)
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to the request
+func (s *S3) sign(req *http.Request, body []byte) {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now().UTC()
+	amzDate := t.Format(amzDateFormat)
+	day := amzDate[:8]
+	payload := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(payload[:])
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders, canonicalHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapeURIPath(req.URL.Path),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + s.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), day)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signedHeaders, signature))
+}
+
+// canonicalHeaders returns the signed header names and the canonical header block, signing host and every
+// x-amz- header
+func canonicalHeaders(req *http.Request) (string, string) {
+	values := map[string]string{"host": req.URL.Host}
+	for k, vs := range req.Header {
+		name := strings.ToLower(k)
+		if strings.HasPrefix(name, "x-amz-") || name == "content-type" {
+			values[name] = strings.Join(vs, ",")
+		}
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + strings.TrimSpace(values[name]) + "\n")
+	}
+	return strings.Join(names, ";"), b.String()
+}
+
+// canonicalQuery encodes the query sorted by name with spaces as %20, as the signature requires
+func canonicalQuery(query url.Values) string {
+	return strings.Replace(query.Encode(), "+", "%20", -1)
+}
+
+// escapeURIPath escapes each segment of the path as required by the signature, leaving the slashes
+func escapeURIPath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		segments[i] = uriEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEscape escapes everything other than the unreserved characters A-Z a-z 0-9 - _ . ~
+func uriEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
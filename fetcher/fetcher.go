@@ -13,19 +13,45 @@ type RSFetcher interface {
 	Fetch(source string) ([]byte, int, error)
 }
 
+// HeaderFetcher is implemented by fetchers able to send extra headers with the request, such as credentials which
+// should not appear in the URL
+type HeaderFetcher interface {
+	RSFetcher
+	FetchWithHeader(source string, header http.Header) ([]byte, int, error)
+}
+
 // ErrNon200Response is returned from the BasicRSFetcher for any non-200 response
 var ErrNon200Response = errors.New("non-200 status code returned")
 
 // BasicRSFetcher is a simple implementation of the Fetcher interface. It is safe to use
-// but limited in capability. No timeouts or extra headers are defined. The general recommendation
-// is that the user of this client write their own implementation of the Fetcher interface.
-type BasicRSFetcher struct{}
+// but limited in capability. No extra headers are defined and, unless a Client with a Timeout is set, there is no
+// timeout. The general recommendation is that the user of this client write their own implementation of the
+// Fetcher interface.
+type BasicRSFetcher struct {
+	Client *http.Client // optional, http.DefaultClient is used if nil
+}
 
 // Fetch retrieves the resource from source and writes it to dest. It is the callers responsibility
 // to clear up any local files when they are finished with.
 // This fetcher implementation will return an error for a non-200 response.
 func (brf *BasicRSFetcher) Fetch(source string) ([]byte, int, error) {
-	res, err := http.Get(source)
+	return brf.FetchWithHeader(source, nil)
+}
+
+// FetchWithHeader behaves as Fetch, sending the given headers with the request
+func (brf *BasicRSFetcher) FetchWithHeader(source string, header http.Header) ([]byte, int, error) {
+	client := brf.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequest(http.MethodGet, source, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error making GET request against: %q: %v", source, err)
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	res, err := client.Do(req)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error making GET request against: %q: %v", source, err)
 	}
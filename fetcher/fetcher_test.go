@@ -3,6 +3,7 @@ package fetcher
 import (
 	"fmt"
 	"html"
+	"net"
 	"net/http"
 	"os"
 	"testing"
@@ -41,8 +42,17 @@ func TestMain(m *testing.M) {
 		w.WriteHeader(http.StatusBadGateway)
 		fmt.Fprintf(w, "Gateway timeout, %q", html.EscapeString(r.URL.Path))
 	})
+	http.HandleFunc("/header", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("X-Test"))
+	})
 
-	go http.ListenAndServe(":7777", nil)
+	// listen before running the tests so the first request cannot race the server starting
+	l, err := net.Listen("tcp", ":7777")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to start test server: %v\n", err)
+		os.Exit(1)
+	}
+	go http.Serve(l, nil)
 	os.Exit(m.Run())
 }
 
@@ -93,3 +103,17 @@ func TestBasicFetcherFetch(t *testing.T) {
 		assert.Equal(t, data, td.expContent)
 	}
 }
+
+func TestBasicFetcherTimeout(t *testing.T) {
+	brf := &BasicRSFetcher{Client: &http.Client{Timeout: 10 * time.Millisecond}}
+	_, _, err := brf.Fetch(baseTestURL + "/slow")
+	assert.NotNil(t, err)
+}
+
+func TestBasicFetcherFetchWithHeader(t *testing.T) {
+	var hf HeaderFetcher = &BasicRSFetcher{}
+	data, status, err := hf.FetchWithHeader(baseTestURL+"/header", http.Header{"X-Test": {"sent"}})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "sent", string(data))
+}
@@ -0,0 +1,136 @@
+package mirror
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nathj07/go-resourcesync/destination"
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+// The outcomes of auditing a resource
+const (
+	AuditMatching = "matching"
+	AuditMissing  = "missing"
+	AuditChanged  = "changed"
+	AuditExtra    = "extra"
+)
+
+// AuditResult describes a resource that does not match the source. Loc is empty for extra resources, which the
+// destination holds but the source no longer lists.
+type AuditResult struct {
+	Status string
+	Loc    string
+	Key    string
+	Reason string
+}
+
+// AuditReport summarises an audit. Details lists every resource that is missing, changed or extra; matching
+// resources are only counted. Errors holds the resources that could not be checked, these are not counted.
+type AuditReport struct {
+	Matching int
+	Missing  int
+	Changed  int
+	Extra    int
+	Details  []AuditResult
+	Errors   []error
+}
+
+// Complete reports if the destination is an exact copy of the source
+func (ar *AuditReport) Complete() bool {
+	return ar.Missing == 0 && ar.Changed == 0 && ar.Extra == 0 && len(ar.Errors) == 0
+}
+
+// Audit walks the resource list, or resource list index, at target and checks each resource is held by dest, as
+// stored by a Mirror, with the published length and hash. Resources held by dest below the hosts seen in the
+// resource list that the source does not list are reported as extra, the mirror StateFile is ignored.
+// If filters are given only the resources matching all of them are expected to be held by dest, though those that do
+// not match are still listed by the source and so are never reported as extra.
+func Audit(rs *resourcesync.ResourceSync, target string, dest destination.Destination, filters ...resourcesync.Filter) (*AuditReport, error) {
+	report := &AuditReport{}
+	listed := map[string]bool{}
+	match := resourcesync.All(filters...)
+	err := rs.Walk(target, func(ru resourcesync.ResourceURL) error {
+		loc := strings.TrimSpace(ru.Loc)
+		key, err := LocalPath(loc)
+		if err != nil {
+			report.Errors = append(report.Errors, err)
+			return nil
+		}
+		listed[key] = true
+		if match(ru) {
+			auditResource(dest, loc, key, ru.RSMD, report)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = unlisted(dest, listed, func(key string) {
+		report.Extra++
+		report.Details = append(report.Details, AuditResult{
+			Status: AuditExtra,
+			Key:    key,
+			Reason: "not in the resource list",
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Audit checks the destination of the Mirror against the source's current resource list
+func (m *Mirror) Audit() (*AuditReport, error) {
+	resourceList, _, err := m.capabilities()
+	if err != nil {
+		return nil, err
+	}
+	if resourceList == "" {
+		return nil, fmt.Errorf("no resource list found in capability list %q", m.CapabilityList)
+	}
+	return Audit(m.RS, resourceList, m.Dest, m.Filters...)
+}
+
+// auditResource compares a single resource by existence, then length and finally hash, adding the outcome to report
+func auditResource(dest destination.Destination, loc, key string, md resourcesync.RSMD, report *AuditReport) {
+	expected, hasHash := resourcesync.PreferredHash(md.Hash)
+	algorithm := ""
+	if hasHash {
+		algorithm = resourcesync.HashAlgorithm(expected)
+	}
+	obj, err := dest.Stat(key, algorithm)
+	if err == destination.ErrNotFound {
+		report.Missing++
+		report.Details = append(report.Details, AuditResult{
+			Status: AuditMissing,
+			Loc:    loc,
+			Key:    key,
+			Reason: "not found in destination",
+		})
+		return
+	}
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Errorf("failed to check %q: %v", loc, err))
+		return
+	}
+	reason := ""
+	if length, err := strconv.ParseInt(strings.TrimSpace(md.Length), 10, 64); err == nil && length != obj.Length {
+		reason = fmt.Sprintf("length %d, expected %d", obj.Length, length)
+	} else if hasHash && obj.Hash != expected {
+		reason = fmt.Sprintf("hash %s, expected %s", obj.Hash, expected)
+	}
+	if reason == "" {
+		report.Matching++
+		return
+	}
+	report.Changed++
+	report.Details = append(report.Details, AuditResult{
+		Status: AuditChanged,
+		Loc:    loc,
+		Key:    key,
+		Reason: reason,
+	})
+}
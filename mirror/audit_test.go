@@ -0,0 +1,97 @@
+package mirror
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nathj07/go-resourcesync/destination"
+	"github.com/nathj07/go-resourcesync/fetcher"
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+func TestAudit(t *testing.T) {
+	src := newTestSource(t)
+	defer src.close()
+	src.write("a.txt", "hello")
+	src.write("b.txt", "world")
+	src.write("sub/c.txt", "nested")
+	src.write("d.txt", "same length")
+	src.publish(nil)
+
+	dir, err := ioutil.TempDir("", "rs-mirror")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	dest := destination.NewLocal(dir)
+	m := New(&fetcher.BasicRSFetcher{}, src.capabilityList(), dest)
+	_, err = m.Sync()
+	require.Nil(t, err)
+
+	report, err := m.Audit()
+	require.Nil(t, err)
+	assert.Equal(t, &AuditReport{Matching: 4}, report)
+	assert.True(t, report.Complete())
+
+	key := func(name string) string {
+		return filepath.ToSlash(src.localPath(t, name))
+	}
+	require.Nil(t, dest.Delete(key("a.txt")))
+	require.Nil(t, dest.Put(key("b.txt"), strings.NewReader("world, changed")))
+	require.Nil(t, dest.Put(key("d.txt"), strings.NewReader("SAME LENGTH")))
+	require.Nil(t, dest.Put(key("sub/extra.txt"), strings.NewReader("extra")))
+	// content from other hosts is not part of this source
+	require.Nil(t, dest.Put("other.example.com/unrelated.txt", strings.NewReader("unrelated")))
+
+	report, err = Audit(resourcesync.New(&fetcher.BasicRSFetcher{}), src.server.URL+"/rs/"+resourcesync.ResourceListFile, dest)
+	require.Nil(t, err)
+	assert.False(t, report.Complete())
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 1, report.Matching)
+	assert.Equal(t, 1, report.Missing)
+	assert.Equal(t, 2, report.Changed)
+	assert.Equal(t, 1, report.Extra)
+
+	statuses := map[string]string{}
+	for _, d := range report.Details {
+		statuses[d.Key] = d.Status
+	}
+	assert.Equal(t, map[string]string{
+		key("a.txt"):         AuditMissing,
+		key("b.txt"):         AuditChanged,
+		key("d.txt"):         AuditChanged,
+		key("sub/extra.txt"): AuditExtra,
+	}, statuses)
+}
+
+func TestAuditFiltered(t *testing.T) {
+	src := newTestSource(t)
+	defer src.close()
+	src.write("a.txt", "hello")
+	src.write("sub/c.txt", "nested")
+	src.publish(nil)
+
+	dir, err := ioutil.TempDir("", "rs-mirror")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	dest := destination.NewLocal(dir)
+	m := New(&fetcher.BasicRSFetcher{}, src.capabilityList(), dest)
+	m.Filters = []resourcesync.Filter{resourcesync.URLPrefix(src.server.URL + "/data/sub/")}
+	_, err = m.Sync()
+	require.Nil(t, err)
+
+	report, err := m.Audit()
+	require.Nil(t, err)
+	assert.Equal(t, &AuditReport{Matching: 1}, report)
+
+	// a listed resource outside the filters is neither expected nor extra
+	require.Nil(t, dest.Put(filepath.ToSlash(src.localPath(t, "a.txt")), strings.NewReader("stale")))
+	report, err = m.Audit()
+	require.Nil(t, err)
+	assert.Equal(t, &AuditReport{Matching: 1}, report)
+	assert.True(t, report.Complete())
+}
@@ -0,0 +1,7 @@
+// package mirror keeps a copy of the resources published by a ResourceSync source.
+// A Mirror is pointed at a capability list and a destination.Destination, such as a local directory or an S3 bucket.
+// The first Sync downloads everything in the resource list, later calls apply the source's change list so only
+// created, updated and deleted resources are touched. Resources whose hash already matches the published hash are
+// never downloaded again.
+
+package mirror
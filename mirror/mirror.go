@@ -0,0 +1,423 @@
+package mirror
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nathj07/go-resourcesync/destination"
+	"github.com/nathj07/go-resourcesync/fetcher"
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+// StateFile is the key the mirror State is stored under in the Destination, recording how up to date the mirror is
+const StateFile = ".resourcesync-mirror.json"
+
+// The capabilities the Mirror looks for in the capability list
+const (
+	capabilityResourceList = "resourcelist"
+	capabilityChangeList   = "changelist"
+)
+
+// State records the progress of a mirror between runs
+type State struct {
+	CapabilityList string `json:"capabilityList"`
+	// Since is the datetime the mirror is known to be up to date to; changes after this are applied on the next Sync
+	Since string `json:"since"`
+}
+
+// Stats summarises the work done by a Sync. Errors holds the failures for individual resources, these do not stop
+// the Sync but do prevent the State moving forward so the resources are retried next time.
+type Stats struct {
+	FullSync   bool
+	Downloaded int
+	Skipped    int
+	Deleted    int
+	Errors     []error
+}
+
+// Mirror keeps Dest in sync with the resources of the source described by CapabilityList, each resource is stored
+// under the key given by LocalPath. RS is used to fetch the ResourceSync documents and Fetcher the resources themselves.
+// If Filters are set only the resources matching all of them are mirrored. A full sync downloads up to Concurrency
+// resources at once, changes are always applied one at a time and in order.
+type Mirror struct {
+	CapabilityList string
+	Dest           destination.Destination
+	RS             *resourcesync.ResourceSync
+	Fetcher        fetcher.RSFetcher
+	Filters        []resourcesync.Filter
+	Concurrency    int
+}
+
+// New is the simplest way to instantiate a ready to use Mirror, using the one fetcher for documents and resources
+func New(f fetcher.RSFetcher, capabilityList string, dest destination.Destination) *Mirror {
+	return &Mirror{
+		CapabilityList: capabilityList,
+		Dest:           dest,
+		RS:             resourcesync.New(f),
+		Fetcher:        f,
+	}
+}
+
+// Sync brings the mirror up to date. If the mirror has been synced before and the source offers a change list
+// covering the time since, only the changes are applied. Otherwise every resource in the resource list is checked,
+// and downloaded if it is missing or its hash does not match, and whatever Dest holds below the hosts of the resource
+// list that it does not list is deleted.
+func (m *Mirror) Sync() (*Stats, error) {
+	state, err := m.loadState()
+	if err != nil {
+		return nil, err
+	}
+	resourceList, changeList, err := m.capabilities()
+	if err != nil {
+		return nil, err
+	}
+	if state.Since != "" && changeList != "" {
+		stats, applied, err := m.applyChangeList(changeList, state)
+		if err != nil || applied {
+			return stats, err
+		}
+	}
+	if resourceList == "" {
+		return nil, fmt.Errorf("no resource list found in capability list %q", m.CapabilityList)
+	}
+	return m.fullSync(resourceList, state)
+}
+
+// capabilities finds the resource list and change list, either of which may be an index, in the capability list
+func (m *Mirror) capabilities() (string, string, error) {
+	rd, err := m.RS.Process(m.CapabilityList)
+	if err != nil {
+		return "", "", err
+	}
+	if rd.RType != resourcesync.Capability {
+		return "", "", fmt.Errorf("%q is not a capability list", m.CapabilityList)
+	}
+	resourceList, changeList := "", ""
+	for _, ru := range rd.RL.URLSet {
+		switch ru.RSMD.Capability {
+		case capabilityResourceList:
+			resourceList = strings.TrimSpace(ru.Loc)
+		case capabilityChangeList:
+			changeList = strings.TrimSpace(ru.Loc)
+		}
+	}
+	return resourceList, changeList, nil
+}
+
+// fullSync visits every resource in the resource list, downloading those that do not match
+func (m *Mirror) fullSync(target string, state *State) (*Stats, error) {
+	rd, err := m.RS.Process(target)
+	if err != nil {
+		return nil, err
+	}
+	at := snapshotTime(rd.Metadata())
+	if at == "" {
+		at = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	stats := &Stats{FullSync: true}
+	listed, err := m.updateAll(rd, stats)
+	if err != nil {
+		return stats, err
+	}
+	// what the source no longer lists has been deleted at some point, whether or not a change list said so
+	var deleted []string
+	if err := unlisted(m.Dest, listed, func(key string) {
+		deleted = append(deleted, key)
+	}); err != nil {
+		return stats, err
+	}
+	for _, key := range deleted {
+		if err := m.Dest.Delete(key); err != nil {
+			stats.Errors = append(stats.Errors, err)
+			continue
+		}
+		stats.Deleted++
+	}
+	if len(stats.Errors) == 0 {
+		state.Since = at
+		return stats, m.saveState(state)
+	}
+	return stats, nil
+}
+
+// updateAll updates every resource in the resource list matching the Filters, using up to Concurrency downloads at
+// once. The keys of all the resources listed, whether or not they match, are returned.
+func (m *Mirror) updateAll(rd *resourcesync.ResourceData, stats *Stats) (map[string]bool, error) {
+	workers := m.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	work := make(chan resourcesync.ResourceURL)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ru := range work {
+				downloaded, err := m.fetch(ru)
+				mu.Lock()
+				stats.record(downloaded, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	listed := map[string]bool{}
+	match := resourcesync.All(m.Filters...)
+	err := m.RS.WalkData(rd, func(ru resourcesync.ResourceURL) error {
+		if key, err := LocalPath(strings.TrimSpace(ru.Loc)); err == nil {
+			listed[key] = true
+		}
+		if match(ru) {
+			work <- ru
+		}
+		return nil
+	})
+	close(work)
+	wg.Wait()
+	return listed, err
+}
+
+// unlisted calls fn with each key held by dest below the hosts of the listed keys that is not itself listed. The
+// mirror StateFile is passed over.
+func unlisted(dest destination.Destination, listed map[string]bool, fn func(key string)) error {
+	hosts := map[string]bool{}
+	for key := range listed {
+		hosts[strings.SplitN(key, "/", 2)[0]+"/"] = true
+	}
+	prefixes := make([]string, 0, len(hosts))
+	for host := range hosts {
+		prefixes = append(prefixes, host)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		err := dest.List(prefix, func(obj destination.Object) error {
+			if !listed[obj.Key] && obj.Key != StateFile {
+				fn(obj.Key)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list destination: %v", err)
+		}
+	}
+	return nil
+}
+
+// applyChangeList applies the changes made since the mirror was last synced. applied is false if the change list
+// does not reach back far enough, in which case a full sync is required.
+func (m *Mirror) applyChangeList(target string, state *State) (*Stats, bool, error) {
+	since, err := resourcesync.ParseDateTime(state.Since)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid mirror state: %v", err)
+	}
+	rd, err := m.RS.Process(target)
+	if err != nil {
+		return nil, false, err
+	}
+	md := rd.Metadata()
+	if md.From != "" {
+		from, err := resourcesync.ParseDateTime(md.From)
+		if err != nil {
+			return nil, false, err
+		}
+		if from.After(since) {
+			return nil, false, nil
+		}
+	}
+
+	type change struct {
+		ru resourcesync.ResourceURL
+		at time.Time
+	}
+	var changes []change
+	latest := since
+	err = m.RS.WalkData(rd, func(ru resourcesync.ResourceURL) error {
+		dateTime := ru.RSMD.DateTime
+		if dateTime == "" {
+			dateTime = ru.LastMod
+		}
+		at, err := resourcesync.ParseDateTime(dateTime)
+		if err != nil {
+			return fmt.Errorf("change to %q: %v", strings.TrimSpace(ru.Loc), err)
+		}
+		// changes made at the moment of the last sync are applied again, they may not have been seen then
+		if !at.Before(since) {
+			changes = append(changes, change{ru: ru, at: at})
+		}
+		if at.After(latest) {
+			latest = at
+		}
+		return nil
+	}, m.Filters...)
+	if err != nil {
+		return nil, false, err
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].at.Before(changes[j].at)
+	})
+
+	stats := &Stats{}
+	for _, c := range changes {
+		if c.ru.RSMD.Change == resourcesync.ChangeDeleted {
+			m.remove(c.ru, stats)
+			continue
+		}
+		m.update(c.ru, stats)
+	}
+	if len(stats.Errors) > 0 {
+		return stats, true, nil
+	}
+	state.Since = md.Until
+	if state.Since == "" {
+		state.Since = latest.UTC().Format(time.RFC3339Nano)
+	}
+	return stats, true, m.saveState(state)
+}
+
+// update downloads the resource unless the mirrored copy already matches the published hash
+func (m *Mirror) update(ru resourcesync.ResourceURL, stats *Stats) {
+	downloaded, err := m.fetch(ru)
+	stats.record(downloaded, err)
+}
+
+// fetch downloads the resource and writes it to Dest, downloaded is false if the mirrored copy already matched
+func (m *Mirror) fetch(ru resourcesync.ResourceURL) (downloaded bool, err error) {
+	loc := strings.TrimSpace(ru.Loc)
+	key, err := LocalPath(loc)
+	if err != nil {
+		return false, err
+	}
+	expected, hasHash := resourcesync.PreferredHash(ru.RSMD.Hash)
+	if hasHash && m.matches(key, expected) {
+		return false, nil
+	}
+	data, status, err := m.Fetcher.Fetch(loc)
+	if err != nil {
+		return false, fmt.Errorf("%d: failed to fetch %q: %v", status, loc, err)
+	}
+	if hasHash {
+		got, err := resourcesync.ComputeHash(bytes.NewReader(data), expected)
+		if err != nil || got != expected {
+			return false, fmt.Errorf("hash mismatch for %q: got %s, expected %s", loc, got, expected)
+		}
+	}
+	if err := m.Dest.Put(key, bytes.NewReader(data)); err != nil {
+		return false, fmt.Errorf("failed to write %q: %v", loc, err)
+	}
+	return true, nil
+}
+
+// record adds the outcome of updating a resource to the stats
+func (s *Stats) record(downloaded bool, err error) {
+	switch {
+	case err != nil:
+		s.Errors = append(s.Errors, err)
+	case downloaded:
+		s.Downloaded++
+	default:
+		s.Skipped++
+	}
+}
+
+func (m *Mirror) remove(ru resourcesync.ResourceURL, stats *Stats) {
+	key, err := LocalPath(strings.TrimSpace(ru.Loc))
+	if err != nil {
+		stats.Errors = append(stats.Errors, err)
+		return
+	}
+	if _, err := m.Dest.Stat(key, ""); err == destination.ErrNotFound {
+		return
+	}
+	if err := m.Dest.Delete(key); err != nil {
+		stats.Errors = append(stats.Errors, err)
+		return
+	}
+	stats.Deleted++
+}
+
+// LocalPath derives the relative, slash separated, path a resource is mirrored to from its Loc; this is the key it is
+// stored under in the Destination. The path is made up of the host and the cleaned URL path; a query string is kept
+// as part of the file name so distinct resources do not collide.
+func LocalPath(loc string) (string, error) {
+	u, err := url.Parse(loc)
+	if err != nil {
+		return "", fmt.Errorf("invalid resource location %q: %v", loc, err)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("resource location %q is not absolute", loc)
+	}
+	// the host is the first element of the path so must not move out of, or within, the mirror root
+	if u.Host == "." || u.Host == ".." || strings.ContainsAny(u.Host, `/\`) {
+		return "", fmt.Errorf("resource location %q has an invalid host", loc)
+	}
+	p := u.Path
+	if p == "" || strings.HasSuffix(p, "/") {
+		p += "index"
+	}
+	// a path such as /a/.. cleans to the root, which would leave the host as the file name
+	if p = path.Clean("/" + p); p == "/" {
+		p = "/index"
+	}
+	if u.RawQuery != "" {
+		p += "_" + url.QueryEscape(u.RawQuery)
+	}
+	rel := path.Join(u.Host, p)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("resource location %q is outside the mirror", loc)
+	}
+	return rel, nil
+}
+
+// matches reports if the destination holds the resource with the expected hash
+func (m *Mirror) matches(key, expected string) bool {
+	obj, err := m.Dest.Stat(key, resourcesync.HashAlgorithm(expected))
+	return err == nil && obj.Hash == expected
+}
+
+func (m *Mirror) loadState() (*State, error) {
+	state := &State{CapabilityList: m.CapabilityList}
+	rc, err := m.Dest.Get(StateFile)
+	if err == destination.ErrNotFound {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid mirror state: %v", err)
+	}
+	if state.CapabilityList != m.CapabilityList {
+		// the destination previously mirrored another source, start again
+		return &State{CapabilityList: m.CapabilityList}, nil
+	}
+	return state, nil
+}
+
+func (m *Mirror) saveState(state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return m.Dest.Put(StateFile, bytes.NewReader(data))
+}
+
+func snapshotTime(md resourcesync.RSMD) string {
+	if md.At != "" {
+		return md.At
+	}
+	return md.Completed
+}
@@ -0,0 +1,284 @@
+package mirror
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nathj07/go-resourcesync/destination"
+	"github.com/nathj07/go-resourcesync/fetcher"
+	"github.com/nathj07/go-resourcesync/resourcesync"
+	"github.com/nathj07/go-resourcesync/server"
+)
+
+func TestMirrorSync(t *testing.T) {
+	src := newTestSource(t)
+	defer src.close()
+	src.write("a.txt", "hello")
+	src.write("b.txt", "world")
+	src.write("sub/c.txt", "nested")
+	first := src.publish(nil)
+
+	dir, err := ioutil.TempDir("", "rs-mirror")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	m := New(&fetcher.BasicRSFetcher{}, src.capabilityList(), destination.NewLocal(dir))
+	m.Concurrency = 2
+
+	stats, err := m.Sync()
+	require.Nil(t, err)
+	assert.Equal(t, &Stats{FullSync: true, Downloaded: 3}, stats)
+	assert.Equal(t, "hello", src.mirrored(t, dir, "a.txt"))
+	assert.Equal(t, "nested", src.mirrored(t, dir, "sub/c.txt"))
+
+	// with no change list on offer the resource list is checked again, everything matches
+	stats, err = m.Sync()
+	require.Nil(t, err)
+	assert.Equal(t, &Stats{FullSync: true, Skipped: 3}, stats)
+
+	src.write("a.txt", "hello again")
+	src.remove("b.txt")
+	src.write("d.txt", "new")
+	src.publish(first)
+
+	stats, err = m.Sync()
+	require.Nil(t, err)
+	require.Empty(t, stats.Errors)
+	assert.False(t, stats.FullSync)
+	assert.Equal(t, 2, stats.Downloaded)
+	assert.Equal(t, 1, stats.Deleted)
+	assert.Equal(t, "hello again", src.mirrored(t, dir, "a.txt"))
+	assert.Equal(t, "new", src.mirrored(t, dir, "d.txt"))
+	_, err = os.Stat(filepath.Join(dir, src.localPath(t, "b.txt")))
+	assert.True(t, os.IsNotExist(err))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, StateFile))
+	require.Nil(t, err)
+	assert.Contains(t, string(data), src.capabilityList())
+}
+
+func TestMirrorFullSyncDeletes(t *testing.T) {
+	src := newTestSource(t)
+	defer src.close()
+	src.write("a.txt", "hello")
+	src.write("b.txt", "world")
+	src.write("sub/c.txt", "nested")
+	src.publish(nil)
+
+	dir, err := ioutil.TempDir("", "rs-mirror")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	dest := destination.NewLocal(dir)
+	m := New(&fetcher.BasicRSFetcher{}, src.capabilityList(), dest)
+	_, err = m.Sync()
+	require.Nil(t, err)
+
+	// without a change list nothing says b.txt went, the resource list no longer having it is enough
+	src.remove("b.txt")
+	src.publish(nil)
+	require.Nil(t, dest.Put(filepath.ToSlash(src.localPath(t, "sub/extra.txt")), strings.NewReader("extra")))
+	require.Nil(t, dest.Put("other.example.com/unrelated.txt", strings.NewReader("unrelated")))
+
+	stats, err := m.Sync()
+	require.Nil(t, err)
+	assert.Equal(t, &Stats{FullSync: true, Skipped: 2, Deleted: 2}, stats)
+	for _, name := range []string{"b.txt", "sub/extra.txt"} {
+		_, err = os.Stat(filepath.Join(dir, src.localPath(t, name)))
+		assert.True(t, os.IsNotExist(err), name)
+	}
+	_, err = os.Stat(filepath.Join(dir, "other.example.com", "unrelated.txt"))
+	assert.Nil(t, err, "other hosts are left alone")
+	_, err = os.Stat(filepath.Join(dir, StateFile))
+	assert.Nil(t, err)
+
+	report, err := m.Audit()
+	require.Nil(t, err)
+	assert.True(t, report.Complete())
+}
+
+func TestMirrorHashMismatch(t *testing.T) {
+	src := newTestSource(t)
+	defer src.close()
+	src.write("a.txt", "hello")
+	src.publish(nil)
+	// change the content after publishing so it no longer matches the published hash
+	src.write("a.txt", "tampered")
+
+	dir, err := ioutil.TempDir("", "rs-mirror")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	m := New(&fetcher.BasicRSFetcher{}, src.capabilityList(), destination.NewLocal(dir))
+
+	stats, err := m.Sync()
+	require.Nil(t, err)
+	assert.Equal(t, 0, stats.Downloaded)
+	require.Len(t, stats.Errors, 1)
+	_, err = os.Stat(filepath.Join(dir, StateFile))
+	assert.True(t, os.IsNotExist(err), "state should not move forward when resources fail")
+}
+
+func TestMirrorFiltered(t *testing.T) {
+	src := newTestSource(t)
+	defer src.close()
+	src.write("a.txt", "hello")
+	src.write("b.pdf", "%PDF-1.4")
+	src.publish(nil)
+
+	dir, err := ioutil.TempDir("", "rs-mirror")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	m := New(&fetcher.BasicRSFetcher{}, src.capabilityList(), destination.NewLocal(dir))
+	m.Filters = []resourcesync.Filter{resourcesync.MIMEType("application/pdf")}
+
+	stats, err := m.Sync()
+	require.Nil(t, err)
+	assert.Equal(t, &Stats{FullSync: true, Downloaded: 1}, stats)
+	assert.Equal(t, "%PDF-1.4", src.mirrored(t, dir, "b.pdf"))
+	_, err = os.Stat(filepath.Join(dir, src.localPath(t, "a.txt")))
+	assert.True(t, os.IsNotExist(err))
+
+	report, err := m.Audit()
+	require.Nil(t, err)
+	assert.True(t, report.Complete())
+	assert.Equal(t, 1, report.Matching)
+}
+
+func TestLocalPath(t *testing.T) {
+	type testData struct {
+		loc    string
+		exp    string
+		expErr bool
+	}
+	testTable := []testData{
+		{loc: "http://example.com/a/b.pdf", exp: "example.com/a/b.pdf"},
+		{loc: "\n\thttp://example.com/a/b.pdf\n\t", expErr: true},
+		{loc: "http://example.com/a/../../../etc/passwd", exp: "example.com/etc/passwd"},
+		{loc: "http://example.com/dir/", exp: "example.com/dir/index"},
+		{loc: "http://example.com", exp: "example.com/index"},
+		{loc: "http://example.com/data/aGVsbG8%3D.json", exp: "example.com/data/aGVsbG8=.json"},
+		{loc: "http://example.com/get?id=1", exp: "example.com/get_id%3D1"},
+		{loc: "/relative/path", expErr: true},
+	}
+	for _, td := range testTable {
+		got, err := LocalPath(td.loc)
+		if td.expErr {
+			assert.NotNil(t, err, td.loc)
+			continue
+		}
+		require.Nil(t, err, td.loc)
+		assert.Equal(t, td.exp, got, td.loc)
+	}
+}
+
+func TestLocalPathTraversal(t *testing.T) {
+	type testData struct {
+		loc    string
+		exp    string
+		expErr bool
+	}
+	testTable := []testData{
+		{loc: "http://../etc/passwd", expErr: true},
+		{loc: "http://./etc/passwd", expErr: true},
+		{loc: "http://%2e%2e/etc/passwd", expErr: true},
+		{loc: "http://a%2fb/etc/passwd", expErr: true},
+		{loc: "http://a%5cb/etc/passwd", expErr: true},
+		{loc: "http:///etc/passwd", expErr: true},
+		{loc: "http://example.com/%2e%2e/%2e%2e/etc/passwd", exp: "example.com/etc/passwd"},
+		{loc: "http://example.com/..%2f..%2fetc/passwd", exp: "example.com/etc/passwd"},
+		{loc: "http://example.com/%2e%2e", exp: "example.com/index"},
+	}
+	for _, td := range testTable {
+		got, err := LocalPath(td.loc)
+		if td.expErr {
+			assert.NotNil(t, err, td.loc)
+			continue
+		}
+		require.Nil(t, err, td.loc)
+		assert.Equal(t, td.exp, got, td.loc)
+	}
+}
+
+// testSource is a ResourceSync source published from a local directory and served over HTTP.
+// The resources are served below /data and the ResourceSync documents below /rs.
+type testSource struct {
+	t       *testing.T
+	dataDir string
+	rsDir   string
+	server  *httptest.Server
+	rs      *resourcesync.ResourceSync
+}
+
+func newTestSource(t *testing.T) *testSource {
+	dataDir, err := ioutil.TempDir("", "rs-mirror-data")
+	require.Nil(t, err)
+	rsDir, err := ioutil.TempDir("", "rs-mirror-docs")
+	require.Nil(t, err)
+	mux := http.NewServeMux()
+	mux.Handle("/data/", http.StripPrefix("/data", server.NewDirHandler(dataDir)))
+	mux.Handle("/rs/", http.StripPrefix("/rs", server.NewDirHandler(rsDir)))
+	return &testSource{
+		t:       t,
+		dataDir: dataDir,
+		rsDir:   rsDir,
+		server:  httptest.NewServer(mux),
+		rs:      &resourcesync.ResourceSync{},
+	}
+}
+
+// write sets the content of a resource, its modification time is moved on to ensure it is seen as a change
+func (ts *testSource) write(name, content string) {
+	p := filepath.Join(ts.dataDir, filepath.FromSlash(name))
+	require.Nil(ts.t, os.MkdirAll(filepath.Dir(p), 0755))
+	require.Nil(ts.t, ioutil.WriteFile(p, []byte(content), 0644))
+	future := time.Now().Add(time.Hour)
+	require.Nil(ts.t, os.Chtimes(p, future, future))
+}
+
+func (ts *testSource) remove(name string) {
+	require.Nil(ts.t, os.Remove(filepath.Join(ts.dataDir, filepath.FromSlash(name))))
+}
+
+// publish writes the resource list, and if there is a previous list the change list since then.
+// The new resource list is returned.
+func (ts *testSource) publish(previous *resourcesync.ResourceList) *resourcesync.ResourceList {
+	p := resourcesync.NewPublisher(ts.server.URL+"/rs", ts.rsDir)
+	require.Nil(ts.t, p.PublishDir(ts.dataDir, ts.server.URL+"/data"))
+	data, err := ioutil.ReadFile(filepath.Join(ts.rsDir, resourcesync.ResourceListFile))
+	require.Nil(ts.t, err)
+	rd, err := ts.rs.Parse(data)
+	require.Nil(ts.t, err)
+	if previous != nil {
+		require.Nil(ts.t, p.PublishChangeList(resourcesync.DiffResourceLists(previous, rd.RL)))
+	}
+	return rd.RL
+}
+
+func (ts *testSource) capabilityList() string {
+	return ts.server.URL + "/rs/" + resourcesync.CapabilityListFile
+}
+
+func (ts *testSource) localPath(t *testing.T, name string) string {
+	p, err := LocalPath(ts.server.URL + "/data/" + name)
+	require.Nil(t, err)
+	return filepath.FromSlash(p)
+}
+
+func (ts *testSource) mirrored(t *testing.T, dir, name string) string {
+	data, err := ioutil.ReadFile(filepath.Join(dir, ts.localPath(t, name)))
+	require.Nil(t, err)
+	return string(data)
+}
+
+func (ts *testSource) close() {
+	ts.server.Close()
+	os.RemoveAll(ts.dataDir)
+	os.RemoveAll(ts.rsDir)
+}
@@ -0,0 +1,12 @@
+// package notification implements ResourceSync Change Notification, the push based alternative to polling
+// change lists. Notifications are delivered over WebSub (formerly PubSubHubbub): a source advertises a hub through
+// rel="hub" links in its capability list and subscribers register a callback with that hub.
+//
+// The Subscriber discovers the hub and notification channels from a capability list, handles the hub's
+// verification requests and parses the notification payloads into the resourcesync data structures.
+//
+// On the publishing side a Publisher builds the notification payloads and POSTs them to a hub, it can be set as the
+// Notifier of a resourcesync.Publisher to notify subscribers whenever a change list is published. Hub is a minimal
+// in-process hub allowing the whole loop to run on one machine.
+
+package notification
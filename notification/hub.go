@@ -0,0 +1,230 @@
+package notification
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLeaseSeconds is the lease granted by the Hub when a subscriber does not request one
+const DefaultLeaseSeconds = 10 * 24 * 60 * 60
+
+// Hub is a minimal, in-process WebSub hub. It allows the whole publish and subscribe loop to run on one machine,
+// for instance in tests, without an external hub.
+//
+// Unlike a production hub, subscriptions are verified and notifications delivered synchronously while handling
+// the request that triggered them, and nothing is retried.
+type Hub struct {
+	Client *http.Client
+
+	mu            sync.Mutex
+	subscriptions map[string]map[string]*hubSubscription // topic -> callback -> subscription
+}
+
+type hubSubscription struct {
+	callback string
+	secret   string
+	expires  time.Time
+}
+
+// NewHub is the simplest way to instantiate a ready to use Hub
+func NewHub() *Hub {
+	return &Hub{
+		Client:        http.DefaultClient,
+		subscriptions: map[string]map[string]*hubSubscription{},
+	}
+}
+
+// ServeHTTP handles both subscription requests, sent as forms, and notifications POSTed by a Publisher
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		h.subscribe(w, r)
+		return
+	}
+	h.publish(w, r)
+}
+
+// Subscribers returns the number of active subscriptions to the topic
+func (h *Hub) Subscribers(topic string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	count := 0
+	for _, sub := range h.subscriptions[topic] {
+		if time.Now().Before(sub.expires) {
+			count++
+		}
+	}
+	return count
+}
+
+func (h *Hub) subscribe(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	mode := r.PostForm.Get("hub.mode")
+	topic := r.PostForm.Get("hub.topic")
+	callback := r.PostForm.Get("hub.callback")
+	if (mode != modeSubscribe && mode != modeUnsubscribe) || topic == "" || callback == "" {
+		http.Error(w, "hub.mode, hub.topic and hub.callback are required", http.StatusBadRequest)
+		return
+	}
+	lease := DefaultLeaseSeconds
+	if requested, err := strconv.Atoi(r.PostForm.Get("hub.lease_seconds")); err == nil && requested > 0 {
+		lease = requested
+	}
+	if err := h.verify(mode, topic, callback, lease); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscriptions == nil {
+		h.subscriptions = map[string]map[string]*hubSubscription{}
+	}
+	if mode == modeUnsubscribe {
+		delete(h.subscriptions[topic], callback)
+	} else {
+		if h.subscriptions[topic] == nil {
+			h.subscriptions[topic] = map[string]*hubSubscription{}
+		}
+		h.subscriptions[topic][callback] = &hubSubscription{
+			callback: callback,
+			secret:   r.PostForm.Get("hub.secret"),
+			expires:  time.Now().Add(time.Duration(lease) * time.Second),
+		}
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// verify confirms the subscriber intended the request by having it echo a challenge
+func (h *Hub) verify(mode, topic, callback string, lease int) error {
+	u, err := url.Parse(callback)
+	if err != nil {
+		return fmt.Errorf("invalid hub.callback: %v", err)
+	}
+	// the challenge must not be guessable, or anyone could subscribe a callback they do not control
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate hub.challenge: %v", err)
+	}
+	challenge := hex.EncodeToString(nonce)
+	q := u.Query()
+	q.Set("hub.mode", mode)
+	q.Set("hub.topic", topic)
+	q.Set("hub.challenge", challenge)
+	if mode == modeSubscribe {
+		q.Set("hub.lease_seconds", strconv.Itoa(lease))
+	}
+	u.RawQuery = q.Encode()
+	res, err := h.client().Get(u.String())
+	if err != nil {
+		return fmt.Errorf("error verifying %s request: %v", mode, err)
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 || string(body) != challenge {
+		return fmt.Errorf("subscriber did not confirm %s request for %q", mode, topic)
+	}
+	return nil
+}
+
+// publish distributes the notification to every active subscriber of the topic named in the rel="self" Link header
+func (h *Hub) publish(w http.ResponseWriter, r *http.Request) {
+	links := parseLinks(r.Header["Link"])
+	topic := links["self"]
+	if topic == "" {
+		http.Error(w, `a Link header with rel="self" naming the topic is required`, http.StatusBadRequest)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	h.mu.Lock()
+	var subs []hubSubscription
+	for callback, sub := range h.subscriptions[topic] {
+		if time.Now().After(sub.expires) {
+			delete(h.subscriptions[topic], callback)
+			continue
+		}
+		subs = append(subs, *sub)
+	}
+	h.mu.Unlock()
+
+	for _, sub := range subs {
+		// delivery failures are not retried, the subscriber is expected to catch up from the change lists
+		h.deliver(sub, topic, links["hub"], r.Header.Get("Content-Type"), body)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Hub) deliver(sub hubSubscription, topic, hub, contentType string, body []byte) {
+	req, err := http.NewRequest(http.MethodPost, sub.callback, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Add("Link", fmt.Sprintf(`<%s>; rel="self"`, topic))
+	if hub != "" {
+		req.Header.Add("Link", fmt.Sprintf(`<%s>; rel="hub"`, hub))
+	}
+	if sub.secret != "" {
+		mac := hmac.New(sha256.New, []byte(sub.secret))
+		mac.Write(body)
+		req.Header.Set("X-Hub-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	res, err := h.client().Do(req)
+	if err != nil {
+		return
+	}
+	res.Body.Close()
+}
+
+func (h *Hub) client() *http.Client {
+	if h.Client == nil {
+		return http.DefaultClient
+	}
+	return h.Client
+}
+
+// parseLinks maps the rel of each link in the Link headers to its target
+func parseLinks(headers []string) map[string]string {
+	links := map[string]string{}
+	for _, header := range headers {
+		for _, link := range strings.Split(header, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			for _, param := range parts[1:] {
+				kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+				if len(kv) == 2 && kv[0] == "rel" {
+					for _, rel := range strings.Fields(strings.Trim(kv[1], `"`)) {
+						links[rel] = target
+					}
+				}
+			}
+		}
+	}
+	return links
+}
@@ -0,0 +1,97 @@
+package notification
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nathj07/go-resourcesync/fetcher"
+	"github.com/nathj07/go-resourcesync/resourcesync"
+	"github.com/nathj07/go-resourcesync/server"
+)
+
+// TestPublishSubscribeLoop runs a source, hub and subscriber locally. The subscriber discovers the hub from the
+// source's capability list and receives the changes when the source publishes a change list.
+func TestPublishSubscribeLoop(t *testing.T) {
+	hub := NewHub()
+	hubServer := httptest.NewServer(hub)
+	defer hubServer.Close()
+
+	out, err := ioutil.TempDir("", "rs-notification")
+	require.Nil(t, err)
+	defer os.RemoveAll(out)
+	source := httptest.NewServer(server.NewDirHandler(out))
+	defer source.Close()
+	topic := source.URL + "/notification/changelist"
+	p := resourcesync.NewPublisher(source.URL, out)
+	p.Notifier = NewPublisher(hubServer.URL, topic)
+	require.Nil(t, p.Publish(resourcesync.NewSliceIterator(nil)))
+
+	received := make(chan *resourcesync.ResourceData, 1)
+	sub := NewSubscriber(resourcesync.New(&fetcher.BasicRSFetcher{}), "", func(gotTopic string, rd *resourcesync.ResourceData) {
+		assert.Equal(t, topic, gotTopic)
+		received <- rd
+	})
+	sub.Secret = "s3cret"
+	callback := httptest.NewServer(sub)
+	defer callback.Close()
+	sub.CallbackURL = callback.URL
+
+	require.Nil(t, sub.SubscribeCapabilityList(source.URL+"/"+resourcesync.CapabilityListFile))
+	assert.Equal(t, 1, hub.Subscribers(topic))
+
+	before := &resourcesync.ResourceList{}
+	after := &resourcesync.ResourceList{URLSet: []resourcesync.ResourceURL{{Loc: "http://example.com/new"}}}
+	require.Nil(t, p.PublishChangeList(resourcesync.DiffResourceLists(before, after)))
+	require.Len(t, received, 1)
+	rd := <-received
+	assert.Equal(t, resourcesync.ChangeListNotification, rd.RType)
+	assert.Equal(t, "http://example.com/new", rd.RL.URLSet[0].Loc)
+	assert.Equal(t, resourcesync.ChangeCreated, rd.RL.URLSet[0].RSMD.Change)
+
+	require.Nil(t, sub.Unsubscribe(topic))
+	assert.Equal(t, 0, hub.Subscribers(topic))
+}
+
+func TestHubRejectsUnconfirmedSubscription(t *testing.T) {
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not the challenge"))
+	}))
+	defer subscriber.Close()
+	hub := NewHub()
+
+	form := url.Values{"hub.mode": {"subscribe"}, "hub.topic": {testTopic}, "hub.callback": {subscriber.URL}}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	hub.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, 0, hub.Subscribers(testTopic))
+}
+
+func TestHubPublishRequiresTopic(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewHub().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("<urlset/>")))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestParseLinks(t *testing.T) {
+	links := parseLinks([]string{
+		`<http://example.com/topic>; rel="self", <http://hub.example.com/>; rel=hub`,
+		`<http://example.com/other>; rel="alternate canonical"`,
+	})
+	exp := map[string]string{
+		"self":      "http://example.com/topic",
+		"hub":       "http://hub.example.com/",
+		"alternate": "http://example.com/other",
+		"canonical": "http://example.com/other",
+	}
+	assert.Equal(t, exp, links)
+}
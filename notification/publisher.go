@@ -0,0 +1,100 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+// Publisher sends change notifications for a single topic to a WebSub hub. It implements
+// resourcesync.ChangeNotifier so it can be set as the Notifier of a resourcesync.Publisher, notifying subscribers
+// each time a change list is published.
+type Publisher struct {
+	Hub    string
+	Topic  string
+	Client *http.Client
+}
+
+// NewPublisher is the simplest way to instantiate a ready to use Publisher
+func NewPublisher(hub, topic string) *Publisher {
+	return &Publisher{
+		Hub:    hub,
+		Topic:  topic,
+		Client: http.DefaultClient,
+	}
+}
+
+// NotificationChannel returns the hub and topic notifications are published to
+func (p *Publisher) NotificationChannel() (string, string) {
+	return p.Hub, p.Topic
+}
+
+// NotifyChanges builds the notification payload for the change list and publishes it to the hub
+func (p *Publisher) NotifyChanges(cl *resourcesync.ResourceList) error {
+	return p.Publish(ChangeListNotification(cl))
+}
+
+// Publish POSTs the notification payload to the hub. The topic and hub are given in Link headers, as a hub
+// distributing the payload would pass them on to subscribers.
+func (p *Publisher) Publish(payload *resourcesync.ResourceList) error {
+	data, err := resourcesync.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, p.Hub, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+	req.Header.Add("Link", fmt.Sprintf(`<%s>; rel="self"`, p.Topic))
+	req.Header.Add("Link", fmt.Sprintf(`<%s>; rel="hub"`, p.Hub))
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error publishing notification to hub %q: %v", p.Hub, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%d: hub %q rejected notification for %q", res.StatusCode, p.Hub, p.Topic)
+	}
+	return nil
+}
+
+// ChangeListNotification builds the changelist-notification payload for the entries of a change list.
+// Only the details a subscriber needs to act on each change are carried over.
+func ChangeListNotification(cl *resourcesync.ResourceList) *resourcesync.ResourceList {
+	return notificationPayload(changeListNotification, cl)
+}
+
+// ResourceListNotification builds the resourcelist-notification payload for the entries of a resource list
+func ResourceListNotification(rl *resourcesync.ResourceList) *resourcesync.ResourceList {
+	return notificationPayload(resourceListNotification, rl)
+}
+
+func notificationPayload(capability string, list *resourcesync.ResourceList) *resourcesync.ResourceList {
+	payload := &resourcesync.ResourceList{
+		RSMD: resourcesync.RSMD{Capability: capability},
+	}
+	for _, ru := range list.URLSet {
+		payload.URLSet = append(payload.URLSet, resourcesync.ResourceURL{
+			Loc:     strings.TrimSpace(ru.Loc),
+			LastMod: ru.LastMod,
+			RSLN:    ru.RSLN,
+			Links:   ru.Links,
+			RSMD: resourcesync.RSMD{
+				Change:   ru.RSMD.Change,
+				DateTime: ru.RSMD.DateTime,
+				Hash:     ru.RSMD.Hash,
+				Length:   ru.RSMD.Length,
+				Type:     ru.RSMD.Type,
+			},
+		})
+	}
+	return payload
+}
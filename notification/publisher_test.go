@@ -0,0 +1,69 @@
+package notification
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+func TestChangeListNotification(t *testing.T) {
+	cl := &resourcesync.ResourceList{
+		RSLink: []resourcesync.RSLN{{Rel: "up", Href: "http://example.com/capabilitylist.xml"}},
+		RSMD:   resourcesync.RSMD{Capability: "changelist", From: "2020-01-01T00:00:00Z", Until: "2020-01-08T00:00:00Z"},
+		URLSet: []resourcesync.ResourceURL{
+			{
+				Loc:  "\n\thttp://example.com/res1\n\t",
+				RSMD: resourcesync.RSMD{Change: "created", DateTime: "2020-01-02T00:00:00Z", Hash: "md5:abc", Path: "/ignored"},
+			},
+		},
+	}
+	exp := &resourcesync.ResourceList{
+		RSMD: resourcesync.RSMD{Capability: "changelist-notification"},
+		URLSet: []resourcesync.ResourceURL{
+			{
+				Loc:  "http://example.com/res1",
+				RSMD: resourcesync.RSMD{Change: "created", DateTime: "2020-01-02T00:00:00Z", Hash: "md5:abc"},
+			},
+		},
+	}
+	assert.Equal(t, exp, ChangeListNotification(cl))
+	assert.Equal(t, "resourcelist-notification", ResourceListNotification(cl).RSMD.Capability)
+}
+
+func TestPublisherPublish(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer hub.Close()
+
+	p := NewPublisher(hub.URL, testTopic)
+	require.Nil(t, p.NotifyChanges(&resourcesync.ResourceList{
+		URLSet: []resourcesync.ResourceURL{{Loc: "http://example.com/res1", RSMD: resourcesync.RSMD{Change: "deleted"}}},
+	}))
+	assert.Equal(t, "application/xml", got.Header.Get("Content-Type"))
+	assert.Equal(t, map[string]string{"self": testTopic, "hub": hub.URL}, parseLinks(got.Header["Link"]))
+	rd, err := (&resourcesync.ResourceSync{}).Parse(body)
+	require.Nil(t, err)
+	assert.Equal(t, resourcesync.ChangeListNotification, rd.RType)
+	assert.Equal(t, "deleted", rd.RL.URLSet[0].RSMD.Change)
+}
+
+func TestPublisherPublishRejected(t *testing.T) {
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer hub.Close()
+
+	err := NewPublisher(hub.URL, testTopic).Publish(&resourcesync.ResourceList{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,340 @@
+package notification
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+// The capabilities identifying notification channels in a capability list
+const (
+	resourceListNotification = "resourcelist-notification"
+	changeListNotification   = "changelist-notification"
+)
+
+// The WebSub modes sent in subscription requests and verifications
+const (
+	modeSubscribe   = "subscribe"
+	modeUnsubscribe = "unsubscribe"
+	modeDenied      = "denied"
+)
+
+// topicParam is added to the callback URL so a notification can be matched to its subscription
+const topicParam = "topic"
+
+// maxPayloadSize limits the size of a notification body the Subscriber will read
+const maxPayloadSize = 10 << 20
+
+// ErrNoHub is returned when a capability list does not advertise a hub
+var ErrNoHub = errors.New("no hub advertised in capability list")
+
+// Channel is a notification channel advertised in a capability list; a WebSub topic along with the hub
+// notifications for it are published through.
+type Channel struct {
+	Hub        string
+	Topic      string
+	Capability string
+}
+
+// Discover fetches the capability list at target and returns the notification channels it advertises.
+// A hub can be linked from the capability list as a whole or from an individual channel entry, the latter taking
+// precedence. Every channel is expected to have a hub, if none is found ErrNoHub is returned.
+func Discover(rs *resourcesync.ResourceSync, target string) ([]Channel, error) {
+	rd, err := rs.Process(target)
+	if err != nil {
+		return nil, err
+	}
+	if rd.RType != resourcesync.Capability {
+		return nil, fmt.Errorf("%q is not a capability list", target)
+	}
+	defaultHub := ""
+	for _, ln := range rd.RL.RSLink {
+		if ln.Rel == "hub" {
+			defaultHub = strings.TrimSpace(ln.Href)
+		}
+	}
+	var channels []Channel
+	for _, ru := range rd.RL.URLSet {
+		if ru.RSMD.Capability != resourceListNotification && ru.RSMD.Capability != changeListNotification {
+			continue
+		}
+		hub := defaultHub
+		if ln, ok := ru.Link("hub"); ok {
+			hub = strings.TrimSpace(ln.Href)
+		}
+		if hub == "" {
+			return nil, ErrNoHub
+		}
+		channels = append(channels, Channel{
+			Hub:        hub,
+			Topic:      strings.TrimSpace(ru.Loc),
+			Capability: ru.RSMD.Capability,
+		})
+	}
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("no notification channels advertised in %q", target)
+	}
+	return channels, nil
+}
+
+// NotificationHandler is called for each verified notification received by the Subscriber
+type NotificationHandler func(topic string, rd *resourcesync.ResourceData)
+
+// Subscription holds the state of a subscription to a single topic
+type Subscription struct {
+	Channel
+	Verified bool
+	Denied   bool
+	Reason   string    // the reason given by the hub for a denied subscription
+	Expires  time.Time // when the verified lease runs out and the subscription must be renewed
+	pending  string    // the mode awaiting verification by the hub
+}
+
+// Subscriber subscribes to notification channels and receives their notifications. It is an http.Handler which
+// must be reachable by the hub at CallbackURL, it answers the hub's verification requests and parses the
+// notifications that are then passed to OnNotification.
+type Subscriber struct {
+	CallbackURL    string
+	Secret         string // if set, notifications must be signed by the hub using this secret
+	LeaseSeconds   int    // the lease requested from the hub, the hub decides if not set
+	OnNotification NotificationHandler
+	Client         *http.Client
+	RS             *resourcesync.ResourceSync
+
+	mu            sync.Mutex
+	subscriptions map[string]*Subscription
+}
+
+// NewSubscriber is the simplest way to instantiate a ready to use Subscriber
+func NewSubscriber(rs *resourcesync.ResourceSync, callbackURL string, onNotification NotificationHandler) *Subscriber {
+	return &Subscriber{
+		CallbackURL:    callbackURL,
+		OnNotification: onNotification,
+		Client:         http.DefaultClient,
+		RS:             rs,
+		subscriptions:  map[string]*Subscription{},
+	}
+}
+
+// SubscribeCapabilityList discovers the notification channels in the capability list at target and subscribes to
+// each of them.
+func (s *Subscriber) SubscribeCapabilityList(target string) error {
+	channels, err := Discover(s.RS, target)
+	if err != nil {
+		return err
+	}
+	for _, ch := range channels {
+		if err := s.Subscribe(ch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe asks the hub to subscribe the Subscriber to the channel topic. The subscription is not active until
+// the hub has verified it, see Subscriptions.
+func (s *Subscriber) Subscribe(ch Channel) error {
+	return s.request(ch, modeSubscribe)
+}
+
+// Unsubscribe asks the hub to stop sending notifications for the topic
+func (s *Subscriber) Unsubscribe(topic string) error {
+	s.mu.Lock()
+	sub, ok := s.subscriptions[topic]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("not subscribed to %q", topic)
+	}
+	return s.request(sub.Channel, modeUnsubscribe)
+}
+
+// Subscriptions returns a snapshot of the current subscriptions
+func (s *Subscriber) Subscriptions() []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subs := make([]Subscription, 0, len(s.subscriptions))
+	for _, sub := range s.subscriptions {
+		subs = append(subs, *sub)
+	}
+	return subs
+}
+
+func (s *Subscriber) request(ch Channel, mode string) error {
+	s.mu.Lock()
+	if s.subscriptions == nil {
+		s.subscriptions = map[string]*Subscription{}
+	}
+	sub, ok := s.subscriptions[ch.Topic]
+	if !ok {
+		sub = &Subscription{Channel: ch}
+		s.subscriptions[ch.Topic] = sub
+	}
+	sub.pending = mode
+	s.mu.Unlock()
+
+	form := url.Values{
+		"hub.mode":     {mode},
+		"hub.topic":    {ch.Topic},
+		"hub.callback": {s.callback(ch.Topic)},
+	}
+	if mode == modeSubscribe {
+		if s.LeaseSeconds > 0 {
+			form.Set("hub.lease_seconds", strconv.Itoa(s.LeaseSeconds))
+		}
+		if s.Secret != "" {
+			form.Set("hub.secret", s.Secret)
+		}
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.PostForm(ch.Hub, form)
+	if err != nil {
+		return fmt.Errorf("error making %s request to hub %q: %v", mode, ch.Hub, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%d: hub %q rejected %s request for %q", res.StatusCode, ch.Hub, mode, ch.Topic)
+	}
+	return nil
+}
+
+// callback builds the callback URL for a topic
+func (s *Subscriber) callback(topic string) string {
+	sep := "?"
+	if strings.Contains(s.CallbackURL, "?") {
+		sep = "&"
+	}
+	return s.CallbackURL + sep + topicParam + "=" + url.QueryEscape(topic)
+}
+
+// ServeHTTP handles the requests made by the hub to the callback URL. GET requests verify subscription changes
+// and POST requests deliver notifications.
+func (s *Subscriber) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.verify(w, r)
+	case http.MethodPost:
+		s.receive(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// verify confirms, or rejects, a hub's verification of a subscription change by echoing the challenge
+func (s *Subscriber) verify(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	mode := q.Get("hub.mode")
+	topic := q.Get("hub.topic")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subscriptions[topic]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	switch mode {
+	case modeDenied:
+		sub.Verified = false
+		sub.Denied = true
+		sub.Reason = q.Get("hub.reason")
+		sub.pending = ""
+		w.WriteHeader(http.StatusOK)
+		return
+	case modeSubscribe, modeUnsubscribe:
+		if sub.pending != mode {
+			http.NotFound(w, r)
+			return
+		}
+	default:
+		http.Error(w, "unsupported hub.mode", http.StatusBadRequest)
+		return
+	}
+	if mode == modeSubscribe {
+		sub.Verified = true
+		sub.Denied = false
+		sub.pending = ""
+		if lease, err := strconv.Atoi(q.Get("hub.lease_seconds")); err == nil {
+			sub.Expires = time.Now().Add(time.Duration(lease) * time.Second)
+		}
+	} else {
+		delete(s.subscriptions, topic)
+	}
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, q.Get("hub.challenge"))
+}
+
+// receive accepts a notification. Per WebSub a 2xx is returned even when the notification is ignored, for instance
+// because the signature does not match, so the hub does not retry it.
+func (s *Subscriber) receive(w http.ResponseWriter, r *http.Request) {
+	topic := r.URL.Query().Get(topicParam)
+	s.mu.Lock()
+	sub, ok := s.subscriptions[topic]
+	active := ok && sub.Verified
+	s.mu.Unlock()
+	if !active {
+		http.NotFound(w, r)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	if s.Secret != "" && !validSignature(s.Secret, r.Header.Get("X-Hub-Signature"), body) {
+		return
+	}
+	rd, err := s.RS.Parse(body)
+	if err != nil {
+		return
+	}
+	if s.OnNotification != nil {
+		s.OnNotification(topic, rd)
+	}
+}
+
+// validSignature checks the X-Hub-Signature header, of the form method=hexdigest, against the body
+func validSignature(secret, header string, body []byte) bool {
+	parts := strings.SplitN(header, "=", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	var h func() hash.Hash
+	switch parts[0] {
+	case "sha1":
+		h = sha1.New
+	case "sha256":
+		h = sha256.New
+	case "sha384":
+		h = sha512.New384
+	case "sha512":
+		h = sha512.New
+	default:
+		return false
+	}
+	got, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,233 @@
+package notification
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nathj07/go-resourcesync/fetcher"
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+func TestDiscover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, string(testNotificationCapabilityList))
+	}))
+	defer server.Close()
+
+	channels, err := Discover(resourcesync.New(&fetcher.BasicRSFetcher{}), server.URL)
+	require.Nil(t, err)
+	exp := []Channel{
+		{
+			Hub:        "http://hub.example.com/",
+			Topic:      "http://example.com/dataset1/notification/changelist",
+			Capability: "changelist-notification",
+		},
+		{
+			Hub:        "http://other-hub.example.com/",
+			Topic:      "http://example.com/dataset1/notification/resourcelist",
+			Capability: "resourcelist-notification",
+		},
+	}
+	assert.Equal(t, exp, channels)
+}
+
+func TestDiscoverNoHub(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, string(bytes.Replace(testNotificationCapabilityList, []byte(`rel="hub"`), []byte(`rel="other"`), -1)))
+	}))
+	defer server.Close()
+
+	_, err := Discover(resourcesync.New(&fetcher.BasicRSFetcher{}), server.URL)
+	assert.Equal(t, ErrNoHub, err)
+}
+
+func TestSubscriberLifecycle(t *testing.T) {
+	received := make(chan *resourcesync.ResourceData, 1)
+	sub := NewSubscriber(&resourcesync.ResourceSync{}, "", func(topic string, rd *resourcesync.ResourceData) {
+		assert.Equal(t, testTopic, topic)
+		received <- rd
+	})
+	sub.Secret = "s3cret"
+	sub.LeaseSeconds = 3600
+	callbackServer := httptest.NewServer(sub)
+	defer callbackServer.Close()
+	sub.CallbackURL = callbackServer.URL + "/callback"
+
+	hub := &fakeHub{t: t}
+	hubServer := httptest.NewServer(hub)
+	defer hubServer.Close()
+
+	require.Nil(t, sub.Subscribe(Channel{Hub: hubServer.URL, Topic: testTopic}))
+	assert.Equal(t, "s3cret", hub.form.Get("hub.secret"))
+	assert.Equal(t, "3600", hub.form.Get("hub.lease_seconds"))
+	subs := sub.Subscriptions()
+	require.Len(t, subs, 1)
+	assert.True(t, subs[0].Verified)
+	assert.False(t, subs[0].Expires.IsZero())
+
+	// a badly signed notification is accepted but ignored
+	res := postNotification(t, hub.form.Get("hub.callback"), "wrong", testNotification)
+	assert.Equal(t, http.StatusAccepted, res.StatusCode)
+	assert.Len(t, received, 0)
+
+	res = postNotification(t, hub.form.Get("hub.callback"), "s3cret", testNotification)
+	assert.Equal(t, http.StatusAccepted, res.StatusCode)
+	require.Len(t, received, 1)
+	rd := <-received
+	assert.Equal(t, resourcesync.ChangeListNotification, rd.RType)
+	require.Len(t, rd.RL.URLSet, 1)
+	assert.Equal(t, "updated", rd.RL.URLSet[0].RSMD.Change)
+
+	require.Nil(t, sub.Unsubscribe(testTopic))
+	assert.Equal(t, "unsubscribe", hub.form.Get("hub.mode"))
+	assert.Len(t, sub.Subscriptions(), 0)
+}
+
+func TestSubscriberVerification(t *testing.T) {
+	sub := NewSubscriber(&resourcesync.ResourceSync{}, "http://localhost/callback", nil)
+	sub.subscriptions[testTopic] = &Subscription{Channel: Channel{Topic: testTopic}, pending: modeSubscribe}
+
+	type testData struct {
+		tag       string
+		mode      string
+		topic     string
+		expStatus int
+		expBody   string
+	}
+	testTable := []testData{
+		{
+			tag:       "UNKNOWN-TOPIC",
+			mode:      modeSubscribe,
+			topic:     "http://example.com/unknown",
+			expStatus: http.StatusNotFound,
+		},
+		{
+			tag:       "NOT-PENDING",
+			mode:      modeUnsubscribe,
+			topic:     testTopic,
+			expStatus: http.StatusNotFound,
+		},
+		{
+			tag:       "SUBSCRIBE",
+			mode:      modeSubscribe,
+			topic:     testTopic,
+			expStatus: http.StatusOK,
+			expBody:   "challenge",
+		},
+		{
+			tag:       "DENIED",
+			mode:      modeDenied,
+			topic:     testTopic,
+			expStatus: http.StatusOK,
+		},
+	}
+	for _, td := range testTable {
+		t.Run(td.tag, func(t *testing.T) {
+			q := url.Values{"hub.mode": {td.mode}, "hub.topic": {td.topic}, "hub.challenge": {"challenge"}}
+			w := httptest.NewRecorder()
+			sub.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/callback?"+q.Encode(), nil))
+			assert.Equal(t, td.expStatus, w.Code)
+			if td.expStatus == http.StatusOK {
+				assert.Equal(t, td.expBody, w.Body.String())
+			}
+		})
+	}
+	assert.True(t, sub.subscriptions[testTopic].Denied)
+	assert.False(t, sub.subscriptions[testTopic].Verified)
+}
+
+func TestValidSignature(t *testing.T) {
+	body := []byte("payload")
+	assert.True(t, validSignature("key", "sha256="+sign("key", body), body))
+	assert.False(t, validSignature("key", "sha256="+sign("other", body), body))
+	assert.False(t, validSignature("key", "md5=abc", body))
+	assert.False(t, validSignature("key", "", body))
+}
+
+// fakeHub records the last subscription request and immediately verifies it against the callback
+type fakeHub struct {
+	t    *testing.T
+	form url.Values
+}
+
+func (h *fakeHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	require.Nil(h.t, r.ParseForm())
+	h.form = r.PostForm
+	callback, err := url.Parse(r.PostForm.Get("hub.callback"))
+	require.Nil(h.t, err)
+	q := callback.Query()
+	q.Set("hub.mode", r.PostForm.Get("hub.mode"))
+	q.Set("hub.topic", r.PostForm.Get("hub.topic"))
+	q.Set("hub.challenge", "abc123")
+	q.Set("hub.lease_seconds", "3600")
+	callback.RawQuery = q.Encode()
+	res, err := http.Get(callback.String())
+	require.Nil(h.t, err)
+	defer res.Body.Close()
+	body, _ := ioutil.ReadAll(res.Body)
+	assert.Equal(h.t, "abc123", string(body))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func postNotification(t *testing.T, callback, secret string, body []byte) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, callback, bytes.NewReader(body))
+	require.Nil(t, err)
+	req.Header.Set("Content-Type", "application/xml")
+	req.Header.Set("X-Hub-Signature", "sha256="+sign(secret, body))
+	res, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	res.Body.Close()
+	return res
+}
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+//
+// Test Data
+//
+
+const testTopic = "http://example.com/dataset1/notification/changelist"
+
+var testNotificationCapabilityList = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:ln rel="up" href="http://example.com/.well-known/resourcesync"/>
+	<rs:ln rel="hub" href="http://hub.example.com/"/>
+	<rs:md capability="capabilitylist"/>
+	<url>
+		<loc>http://example.com/dataset1/changelist.xml</loc>
+		<rs:md capability="changelist"/>
+	</url>
+	<url>
+		<loc>http://example.com/dataset1/notification/changelist</loc>
+		<rs:md capability="changelist-notification"/>
+	</url>
+	<url>
+		<loc>http://example.com/dataset1/notification/resourcelist</loc>
+		<rs:md capability="resourcelist-notification"/>
+		<rs:ln rel="hub" href="http://other-hub.example.com/"/>
+	</url>
+</urlset>`)
+
+var testNotification = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:md capability="changelist-notification"/>
+	<url>
+		<loc>http://example.com/res1</loc>
+		<rs:md change="updated" datetime="2020-01-02T13:00:00Z"/>
+	</url>
+</urlset>`)
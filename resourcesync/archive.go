@@ -0,0 +1,139 @@
+package resourcesync
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoArchivedList is returned when an archive holds no list covering the requested date
+var ErrNoArchivedList = errors.New("no archived list covers the requested date")
+
+// dateTimeLayouts are the forms of W3C datetime seen in ResourceSync feeds, the CORE feeds omit the time zone
+var dateTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// ParseDateTime parses the W3C datetime values used in the lastmod, at, completed, from, until and datetime
+// fields. Values without a time zone are taken to be UTC.
+func ParseDateTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised datetime %q", value)
+}
+
+// ArchivedResourceLists fetches the resource list archive at target, following an archive index if that is what
+// is found, and returns the entries for resource lists taken between from and until inclusive. A zero from or
+// until leaves that end of the range open. The entries are ordered newest first, ready to page back through
+// with Process.
+func (rs *ResourceSync) ArchivedResourceLists(target string, from, until time.Time) ([]ResourceURL, error) {
+	entries, err := rs.archiveEntries(target, ResourceListArchive, ResourceListArchiveIndex)
+	if err != nil {
+		return nil, err
+	}
+	var matched []ResourceURL
+	times := map[string]time.Time{}
+	for _, ru := range entries {
+		at, err := ParseDateTime(snapshotTime(ru.RSMD))
+		if err != nil {
+			return nil, fmt.Errorf("archived resource list %q: %v", strings.TrimSpace(ru.Loc), err)
+		}
+		if (!from.IsZero() && at.Before(from)) || (!until.IsZero() && at.After(until)) {
+			continue
+		}
+		times[ru.Loc] = at
+		matched = append(matched, ru)
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return times[matched[i].Loc].After(times[matched[j].Loc])
+	})
+	return matched, nil
+}
+
+// ArchivedChangeLists fetches the change list archive at target, following an archive index if that is what is
+// found, and returns the entries for change lists whose from/until period overlaps the range from to until.
+// A zero from or until leaves that end of the range open; a change list without an until is still open.
+// The entries are ordered newest first.
+func (rs *ResourceSync) ArchivedChangeLists(target string, from, until time.Time) ([]ResourceURL, error) {
+	entries, err := rs.archiveEntries(target, ChangeListArchive, ChangeListArchiveIndex)
+	if err != nil {
+		return nil, err
+	}
+	var matched []ResourceURL
+	starts := map[string]time.Time{}
+	for _, ru := range entries {
+		start, err := ParseDateTime(ru.RSMD.From)
+		if err != nil {
+			return nil, fmt.Errorf("archived change list %q: %v", strings.TrimSpace(ru.Loc), err)
+		}
+		if !until.IsZero() && start.After(until) {
+			continue
+		}
+		if ru.RSMD.Until != "" && !from.IsZero() {
+			end, err := ParseDateTime(ru.RSMD.Until)
+			if err != nil {
+				return nil, fmt.Errorf("archived change list %q: %v", strings.TrimSpace(ru.Loc), err)
+			}
+			if end.Before(from) {
+				continue
+			}
+		}
+		starts[ru.Loc] = start
+		matched = append(matched, ru)
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return starts[matched[i].Loc].After(starts[matched[j].Loc])
+	})
+	return matched, nil
+}
+
+// ResourceListAt returns the resource list describing the source as it was at the given time, that is the most
+// recent archived resource list taken at or before it. ErrNoArchivedList is returned if the archive does not go
+// back that far.
+func (rs *ResourceSync) ResourceListAt(target string, at time.Time) (*ResourceData, error) {
+	entries, err := rs.ArchivedResourceLists(target, time.Time{}, at)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, ErrNoArchivedList
+	}
+	return rs.Process(strings.TrimSpace(entries[0].Loc))
+}
+
+// archiveEntries fetches the archive, or every archive referenced by an archive index. Archive indexes are not
+// nested so the archives referenced from an index must themselves be lists.
+func (rs *ResourceSync) archiveEntries(target string, listType, indexType int) ([]ResourceURL, error) {
+	rd, err := rs.Process(target)
+	if err != nil {
+		return nil, err
+	}
+	switch rd.RType {
+	case listType:
+		return rd.RL.URLSet, nil
+	case indexType:
+		var entries []ResourceURL
+		for _, archive := range rd.RLI.IndexSet {
+			more, err := rs.archiveEntries(strings.TrimSpace(archive.Loc), listType, Unknown)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, more...)
+		}
+		return entries, nil
+	default:
+		return nil, fmt.Errorf("%q is not the expected archive type: %v", target, ErrUnsupportedFeedType)
+	}
+}
@@ -0,0 +1,188 @@
+package resourcesync
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nathj07/go-resourcesync/fetcher"
+)
+
+func TestParseDateTime(t *testing.T) {
+	type testData struct {
+		value string
+		exp   time.Time
+	}
+	testTable := []testData{
+		{value: "2017-05-16T13:55:36Z", exp: time.Date(2017, 5, 16, 13, 55, 36, 0, time.UTC)},
+		{value: "2017-05-16T14:55:36+01:00", exp: time.Date(2017, 5, 16, 13, 55, 36, 0, time.UTC)},
+		{value: "2020-06-24T20:36:26.441694", exp: time.Date(2020, 6, 24, 20, 36, 26, 441694000, time.UTC)},
+		{value: "2020-06-02T00:00:00", exp: time.Date(2020, 6, 2, 0, 0, 0, 0, time.UTC)},
+		{value: "2020-06-02", exp: time.Date(2020, 6, 2, 0, 0, 0, 0, time.UTC)},
+		{value: " 2020 ", exp: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, td := range testTable {
+		got, err := ParseDateTime(td.value)
+		require.Nil(t, err, td.value)
+		assert.True(t, td.exp.Equal(got), "%s: got %v", td.value, got)
+	}
+	_, err := ParseDateTime("yesterday")
+	assert.NotNil(t, err)
+}
+
+func TestParseArchives(t *testing.T) {
+	rs := &ResourceSync{}
+	testTable := map[string]int{
+		string(testResourceListArchive): ResourceListArchive,
+		string(testChangeListArchive):   ChangeListArchive,
+		strings.Replace(string(testResourceListArchiveIndex), "{{.URL}}", "http://example.com", -1): ResourceListArchiveIndex,
+	}
+	for feed, exp := range testTable {
+		rd, err := rs.Parse([]byte(feed))
+		require.Nil(t, err)
+		assert.Equal(t, exp, rd.RType)
+	}
+}
+
+func TestArchivedResourceLists(t *testing.T) {
+	server := archiveServer()
+	defer server.Close()
+	rs := New(&fetcher.BasicRSFetcher{})
+
+	all, err := rs.ArchivedResourceLists(server.URL+"/resourcelist-archive-index.xml", time.Time{}, time.Time{})
+	require.Nil(t, err)
+	assert.Equal(t, []string{
+		"http://example.com/resourcelist-3.xml",
+		server.URL + "/resourcelist-2.xml",
+		"http://example.com/resourcelist-1.xml",
+	}, locs(all))
+
+	from := time.Date(2013, 1, 2, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2013, 1, 3, 12, 0, 0, 0, time.UTC)
+	ranged, err := rs.ArchivedResourceLists(server.URL+"/resourcelist-archive.xml", from, until)
+	require.Nil(t, err)
+	assert.Equal(t, []string{server.URL + "/resourcelist-2.xml"}, locs(ranged))
+}
+
+func TestArchivedChangeLists(t *testing.T) {
+	server := archiveServer()
+	defer server.Close()
+	rs := New(&fetcher.BasicRSFetcher{})
+
+	from := time.Date(2013, 1, 2, 12, 0, 0, 0, time.UTC)
+	until := time.Date(2013, 1, 3, 12, 0, 0, 0, time.UTC)
+	got, err := rs.ArchivedChangeLists(server.URL+"/changelist-archive.xml", from, until)
+	require.Nil(t, err)
+	assert.Equal(t, []string{
+		"http://example.com/changelist-3.xml",
+		"http://example.com/changelist-2.xml",
+	}, locs(got))
+
+	_, err = rs.ArchivedChangeLists(server.URL+"/resourcelist-archive.xml", from, until)
+	assert.NotNil(t, err)
+}
+
+func TestResourceListAt(t *testing.T) {
+	server := archiveServer()
+	defer server.Close()
+	rs := New(&fetcher.BasicRSFetcher{})
+
+	rd, err := rs.ResourceListAt(server.URL+"/resourcelist-archive.xml", time.Date(2013, 1, 2, 12, 0, 0, 0, time.UTC))
+	require.Nil(t, err)
+	assert.Equal(t, "2013-01-02T09:00:00Z", rd.RL.RSMD.At)
+
+	_, err = rs.ResourceListAt(server.URL+"/resourcelist-archive.xml", time.Date(2012, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, ErrNoArchivedList, err)
+}
+
+// archiveServer serves the archive test data, the archived resource lists are all served from a single handler
+func archiveServer() *httptest.Server {
+	mux := http.NewServeMux()
+	var server *httptest.Server
+	serve := func(path string, body []byte) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, strings.Replace(string(body), "{{.URL}}", server.URL, -1))
+		})
+	}
+	serve("/resourcelist-archive-index.xml", testResourceListArchiveIndex)
+	serve("/resourcelist-archive.xml", testResourceListArchive)
+	serve("/resourcelist-archive-old.xml", testResourceListArchiveOld)
+	serve("/changelist-archive.xml", testChangeListArchive)
+	mux.HandleFunc("/resourcelist-2.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:md capability="resourcelist" at="2013-01-02T09:00:00Z"/>
+</urlset>`)
+	})
+	server = httptest.NewServer(mux)
+	return server
+}
+
+func locs(urls []ResourceURL) []string {
+	var got []string
+	for _, ru := range urls {
+		got = append(got, strings.TrimSpace(ru.Loc))
+	}
+	return got
+}
+
+//
+// Test Data
+//
+
+var testResourceListArchiveIndex = []byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:md capability="resourcelist-archive"/>
+	<sitemap>
+		<loc>{{.URL}}/resourcelist-archive-old.xml</loc>
+	</sitemap>
+	<sitemap>
+		<loc>{{.URL}}/resourcelist-archive.xml</loc>
+	</sitemap>
+</sitemapindex>`)
+
+var testResourceListArchiveOld = []byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:md capability="resourcelist-archive"/>
+	<url>
+		<loc>http://example.com/resourcelist-1.xml</loc>
+		<rs:md at="2013-01-01T09:00:00Z" completed="2013-01-01T09:05:00Z"/>
+	</url>
+</urlset>`)
+
+var testResourceListArchive = []byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:ln rel="up" href="http://example.com/capabilitylist.xml"/>
+	<rs:md capability="resourcelist-archive"/>
+	<url>
+		<loc>{{.URL}}/resourcelist-2.xml</loc>
+		<rs:md at="2013-01-02T09:00:00Z" completed="2013-01-02T09:05:00Z"/>
+	</url>
+	<url>
+		<loc>http://example.com/resourcelist-3.xml</loc>
+		<rs:md at="2013-01-04T09:00:00Z" completed="2013-01-04T09:05:00Z"/>
+	</url>
+</urlset>`)
+
+var testChangeListArchive = []byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:ln rel="up" href="http://example.com/capabilitylist.xml"/>
+	<rs:md capability="changelist-archive"/>
+	<url>
+		<loc>http://example.com/changelist-1.xml</loc>
+		<rs:md from="2013-01-01T00:00:00Z" until="2013-01-02T00:00:00Z"/>
+	</url>
+	<url>
+		<loc>http://example.com/changelist-2.xml</loc>
+		<rs:md from="2013-01-02T00:00:00Z" until="2013-01-03T00:00:00Z"/>
+	</url>
+	<url>
+		<loc>http://example.com/changelist-3.xml</loc>
+		<rs:md from="2013-01-03T00:00:00Z"/>
+	</url>
+	<url>
+		<loc>http://example.com/changelist-4.xml</loc>
+		<rs:md from="2013-01-04T00:00:00Z"/>
+	</url>
+</urlset>`)
@@ -0,0 +1,145 @@
+package resourcesync
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// The values of the change attribute on the rs:md of a change list entry
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// DiffResourceLists compares two snapshots of a resource list and returns the change list describing how to get
+// from before to after. Resources are matched by Loc; a resource is considered updated when its hash differs, compared
+// in an algorithm both snapshots publish, or otherwise when its lastmod or length differs.
+//
+// The from and until of the change list are taken from the at (or completed) times of the snapshots. Without one
+// from falls back to the latest lastmod in before, and is left empty if there is none, and until falls back to the
+// current time. Entries are dated within that period and ordered by their datetime as the specification requires.
+func DiffResourceLists(before, after *ResourceList) *ResourceList {
+	until := snapshotTime(after.RSMD)
+	if until == "" {
+		until = timestamp(time.Now())
+	}
+	from := snapshotTime(before.RSMD)
+	if from == "" {
+		from = latestLastMod(before.URLSet)
+	}
+	cl := &ResourceList{
+		RSMD: RSMD{
+			Capability: changeList,
+			From:       from,
+			Until:      until,
+		},
+	}
+	for _, ln := range after.RSLink {
+		if ln.Rel == "up" {
+			cl.RSLink = append(cl.RSLink, ln)
+		}
+	}
+
+	previous := make(map[string]ResourceURL, len(before.URLSet))
+	for _, ru := range before.URLSet {
+		previous[strings.TrimSpace(ru.Loc)] = ru
+	}
+	for _, ru := range after.URLSet {
+		loc := strings.TrimSpace(ru.Loc)
+		old, found := previous[loc]
+		delete(previous, loc)
+		switch {
+		case !found:
+			cl.URLSet = append(cl.URLSet, changeEntry(ru, ChangeCreated, from, until))
+		case resourceChanged(old, ru):
+			cl.URLSet = append(cl.URLSet, changeEntry(ru, ChangeUpdated, from, until))
+		}
+	}
+	for _, ru := range before.URLSet {
+		if _, deleted := previous[strings.TrimSpace(ru.Loc)]; deleted {
+			cl.URLSet = append(cl.URLSet, changeEntry(ru, ChangeDeleted, from, until))
+		}
+	}
+	// datetimes may be given in different time zones or precisions, so are compared as times rather than strings
+	times := make(map[string]time.Time, len(cl.URLSet))
+	for _, ru := range cl.URLSet {
+		times[ru.RSMD.DateTime], _ = ParseDateTime(ru.RSMD.DateTime)
+	}
+	sort.SliceStable(cl.URLSet, func(i, j int) bool {
+		return times[cl.URLSet[i].RSMD.DateTime].Before(times[cl.URLSet[j].RSMD.DateTime])
+	})
+	return cl
+}
+
+// latestLastMod returns the latest valid lastmod of the entries, or "" if none has one
+func latestLastMod(urls []ResourceURL) string {
+	var latest time.Time
+	for _, ru := range urls {
+		if at, err := ParseDateTime(ru.LastMod); err == nil && at.After(latest) {
+			latest = at
+		}
+	}
+	if latest.IsZero() {
+		return ""
+	}
+	return timestamp(latest)
+}
+
+// resourceChanged compares two entries for the same Loc, preferring the hash when both carry one in the same algorithm
+func resourceChanged(old, current ResourceURL) bool {
+	if oldHash, currentHash, ok := commonHash(old.RSMD.Hash, current.RSMD.Hash); ok {
+		return oldHash != currentHash
+	}
+	return old.LastMod != current.LastMod || old.RSMD.Length != current.RSMD.Length
+}
+
+// changeEntry builds the change list entry for ru. The datetime of a created or updated resource is its lastmod,
+// if known, brought within the change list period: a resource can change without its lastmod moving.
+// Deletions, and resources without a lastmod, are dated at the end of the period.
+func changeEntry(ru ResourceURL, change, from, until string) ResourceURL {
+	dateTime := until
+	if change != ChangeDeleted {
+		dateTime = changeDateTime(ru.LastMod, from, until)
+	}
+	entry := ResourceURL{
+		Loc:   strings.TrimSpace(ru.Loc),
+		RSLN:  ru.RSLN,
+		Links: ru.Links,
+		RSMD: RSMD{
+			Change:   change,
+			DateTime: dateTime,
+		},
+	}
+	if change != ChangeDeleted {
+		entry.RSMD.Hash = ru.RSMD.Hash
+		entry.RSMD.Length = ru.RSMD.Length
+		entry.RSMD.Type = ru.RSMD.Type
+	}
+	return entry
+}
+
+// changeDateTime clamps lastMod to the period between from and until, either of which may be empty. A lastmod that cannot be
+// parsed gives until.
+func changeDateTime(lastMod, from, until string) string {
+	at, err := ParseDateTime(lastMod)
+	if err != nil {
+		return until
+	}
+	if end, err := ParseDateTime(until); err == nil && at.After(end) {
+		return until
+	}
+	if start, err := ParseDateTime(from); err == nil && at.Before(start) {
+		return from
+	}
+	return strings.TrimSpace(lastMod)
+}
+
+// snapshotTime is the moment a resource list snapshot describes
+func snapshotTime(md RSMD) string {
+	if md.At != "" {
+		return md.At
+	}
+	return md.Completed
+}
@@ -0,0 +1,201 @@
+package resourcesync
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffResourceLists(t *testing.T) {
+	before := &ResourceList{
+		RSMD: RSMD{Capability: "resourcelist", At: "2020-01-01T00:00:00Z"},
+		URLSet: []ResourceURL{
+			{Loc: "http://example.com/same", LastMod: "2019-12-01T00:00:00Z", RSMD: RSMD{Hash: "md5:aaa"}},
+			{Loc: "http://example.com/hash-changed", LastMod: "2019-12-01T00:00:00Z", RSMD: RSMD{Hash: "md5:bbb"}},
+			{Loc: "\n\thttp://example.com/lastmod-changed\n\t", LastMod: "2019-12-01T00:00:00Z"},
+			{Loc: "http://example.com/gone", LastMod: "2019-12-01T00:00:00Z", RSMD: RSMD{Hash: "md5:ccc", Length: "10"}},
+		},
+	}
+	after := &ResourceList{
+		RSLink: []RSLN{{Rel: "up", Href: "http://example.com/capabilitylist.xml"}},
+		RSMD:   RSMD{Capability: "resourcelist", At: "2020-01-08T00:00:00Z"},
+		URLSet: []ResourceURL{
+			{Loc: "http://example.com/same", LastMod: "2020-01-05T00:00:00Z", RSMD: RSMD{Hash: "md5:aaa"}},
+			{Loc: "http://example.com/hash-changed", LastMod: "2020-01-03T00:00:00Z", RSMD: RSMD{Hash: "md5:ddd", Length: "20"}},
+			{Loc: "http://example.com/lastmod-changed", LastMod: "2020-01-02T00:00:00Z"},
+			{Loc: "http://example.com/new", RSMD: RSMD{Hash: "md5:eee", Type: "application/pdf"}},
+		},
+	}
+	exp := &ResourceList{
+		RSLink: []RSLN{{Rel: "up", Href: "http://example.com/capabilitylist.xml"}},
+		RSMD: RSMD{
+			Capability: "changelist",
+			From:       "2020-01-01T00:00:00Z",
+			Until:      "2020-01-08T00:00:00Z",
+		},
+		URLSet: []ResourceURL{
+			{
+				Loc:  "http://example.com/lastmod-changed",
+				RSMD: RSMD{Change: ChangeUpdated, DateTime: "2020-01-02T00:00:00Z"},
+			},
+			{
+				Loc:  "http://example.com/hash-changed",
+				RSMD: RSMD{Change: ChangeUpdated, DateTime: "2020-01-03T00:00:00Z", Hash: "md5:ddd", Length: "20"},
+			},
+			{
+				Loc:  "http://example.com/new",
+				RSMD: RSMD{Change: ChangeCreated, DateTime: "2020-01-08T00:00:00Z", Hash: "md5:eee", Type: "application/pdf"},
+			},
+			{
+				Loc:  "http://example.com/gone",
+				RSMD: RSMD{Change: ChangeDeleted, DateTime: "2020-01-08T00:00:00Z"},
+			},
+		},
+	}
+	assert.Equal(t, exp, DiffResourceLists(before, after))
+}
+
+func TestDiffResourceListsFrom(t *testing.T) {
+	after := &ResourceList{RSMD: RSMD{At: "2020-01-08T00:00:00Z"}}
+	testData := []struct {
+		tag    string
+		before *ResourceList
+		exp    string
+	}{
+		{tag: "at", before: &ResourceList{RSMD: RSMD{At: "2020-01-01T00:00:00Z", Completed: "2020-01-01T01:00:00Z"}},
+			exp: "2020-01-01T00:00:00Z"},
+		{tag: "completed", before: &ResourceList{RSMD: RSMD{Completed: "2020-01-01T01:00:00Z"}}, exp: "2020-01-01T01:00:00Z"},
+		{tag: "latest lastmod", before: &ResourceList{URLSet: []ResourceURL{
+			{Loc: "http://example.com/1", LastMod: "2019-12-01T00:00:00Z"},
+			{Loc: "http://example.com/2", LastMod: "2019-12-24T12:00:00+01:00"},
+			{Loc: "http://example.com/3", LastMod: "not a date"},
+			{Loc: "http://example.com/4"},
+		}}, exp: "2019-12-24T11:00:00Z"},
+		{tag: "nothing to go on", before: &ResourceList{URLSet: []ResourceURL{{Loc: "http://example.com/1"}}}, exp: ""},
+	}
+	for _, td := range testData {
+		assert.Equal(t, td.exp, DiffResourceLists(td.before, after).RSMD.From, td.tag)
+	}
+}
+
+func TestDiffResourceListsHashAlgorithms(t *testing.T) {
+	testData := []struct {
+		tag    string
+		before string
+		after  string
+		exp    bool
+	}{
+		{tag: "extra algorithm", before: "md5:aaa sha-256:bbb", after: "md5:aaa", exp: false},
+		{tag: "case", before: "MD5:AAA", after: "md5:aaa", exp: false},
+		{tag: "strongest shared", before: "md5:aaa sha-1:bbb", after: "sha-1:ccc md5:aaa", exp: true},
+		{tag: "none shared", before: "md5:aaa", after: "sha-256:bbb", exp: false},
+		{tag: "changed", before: "md5:aaa", after: "md5:bbb sha-256:ccc", exp: true},
+	}
+	for _, td := range testData {
+		before := &ResourceList{URLSet: []ResourceURL{{Loc: "http://example.com/1", RSMD: RSMD{Hash: td.before}}}}
+		after := &ResourceList{URLSet: []ResourceURL{{Loc: "http://example.com/1", RSMD: RSMD{Hash: td.after}}}}
+		assert.Equal(t, td.exp, len(DiffResourceLists(before, after).URLSet) == 1, td.tag)
+	}
+}
+
+func TestDiffResourceListsDateTimes(t *testing.T) {
+	before := &ResourceList{
+		RSMD: RSMD{At: "2020-01-01T00:00:00Z"},
+		URLSet: []ResourceURL{
+			{Loc: "http://example.com/unchanged-lastmod", LastMod: "2019-12-01T00:00:00Z", RSMD: RSMD{Hash: "md5:aaa"}},
+		},
+	}
+	after := &ResourceList{
+		RSMD: RSMD{At: "2020-01-08T00:00:00Z"},
+		URLSet: []ResourceURL{
+			{Loc: "http://example.com/unchanged-lastmod", LastMod: "2019-12-01T00:00:00Z", RSMD: RSMD{Hash: "md5:bbb"}},
+			{Loc: "http://example.com/future", LastMod: "2020-02-01T00:00:00Z"},
+			{Loc: "http://example.com/offset", LastMod: "2020-01-03T01:00:00+02:00"},
+			{Loc: "http://example.com/utc", LastMod: "2020-01-02T23:30:00Z"},
+		},
+	}
+	var got []string
+	for _, ru := range DiffResourceLists(before, after).URLSet {
+		got = append(got, ru.Loc+" "+ru.RSMD.DateTime)
+	}
+	assert.Equal(t, []string{
+		"http://example.com/unchanged-lastmod 2020-01-01T00:00:00Z",
+		"http://example.com/offset 2020-01-03T01:00:00+02:00",
+		"http://example.com/utc 2020-01-02T23:30:00Z",
+		"http://example.com/future 2020-01-08T00:00:00Z",
+	}, got)
+}
+
+func TestDiffResourceListsParses(t *testing.T) {
+	cl := DiffResourceLists(&ResourceList{}, expListRD.RL)
+	data, err := Marshal(cl)
+	require.Nil(t, err)
+	rd, err := (&ResourceSync{}).Parse(data)
+	require.Nil(t, err)
+	assert.Equal(t, ChangeList, rd.RType)
+	assert.Len(t, rd.RL.URLSet, 2)
+	for _, ru := range rd.RL.URLSet {
+		assert.Equal(t, ChangeCreated, ru.RSMD.Change)
+	}
+}
+
+func TestPublishChangeList(t *testing.T) {
+	out, err := ioutil.TempDir("", "rs-publish-out")
+	require.Nil(t, err)
+	defer os.RemoveAll(out)
+
+	p := NewPublisher(testPublishBase, out)
+	require.Nil(t, p.Publish(NewSliceIterator(expListRD.RL.URLSet)))
+	require.Nil(t, p.PublishChangeList(DiffResourceLists(&ResourceList{}, expListRD.RL)))
+
+	rd := parseFile(t, filepath.Join(out, ChangeListFile))
+	assert.Equal(t, ChangeList, rd.RType)
+	assert.Equal(t, []RSLN{{Rel: "up", Href: testPublishBase + "/capabilitylist.xml"}}, rd.RL.RSLink)
+
+	cl := parseFile(t, filepath.Join(out, CapabilityListFile))
+	require.Len(t, cl.RL.URLSet, 2)
+	assert.Equal(t, ResourceURL{Loc: testPublishBase + "/resourcelist.xml", RSMD: RSMD{Capability: "resourcelist"}}, cl.RL.URLSet[0])
+	assert.Equal(t, ResourceURL{Loc: testPublishBase + "/changelist.xml", RSMD: RSMD{Capability: "changelist"}}, cl.RL.URLSet[1])
+}
+
+func TestPublishChangeListNotifies(t *testing.T) {
+	out, err := ioutil.TempDir("", "rs-publish-out")
+	require.Nil(t, err)
+	defer os.RemoveAll(out)
+
+	notifier := &testNotifier{}
+	p := NewPublisher(testPublishBase, out)
+	p.Notifier = notifier
+	cl := DiffResourceLists(&ResourceList{}, expListRD.RL)
+	links := cl.RSLink
+	require.Nil(t, p.PublishChangeList(cl))
+	assert.Equal(t, cl.URLSet, notifier.notified.URLSet)
+	assert.Equal(t, []RSLN{{Rel: "up", Href: testPublishBase + "/capabilitylist.xml"}}, notifier.notified.RSLink)
+	assert.Equal(t, links, cl.RSLink, "the caller's change list is not changed")
+
+	caps := parseFile(t, filepath.Join(out, CapabilityListFile))
+	require.Len(t, caps.RL.URLSet, 2)
+	exp := ResourceURL{
+		Loc:  "http://example.com/notification/changelist",
+		RSMD: RSMD{Capability: "changelist-notification"},
+		RSLN: RSLN{Rel: "hub", Href: "http://hub.example.com/"},
+	}
+	assert.Equal(t, exp, caps.RL.URLSet[1])
+}
+
+type testNotifier struct {
+	notified *ResourceList
+}
+
+func (tn *testNotifier) NotificationChannel() (string, string) {
+	return "http://hub.example.com/", "http://example.com/notification/changelist"
+}
+
+func (tn *testNotifier) NotifyChanges(cl *ResourceList) error {
+	tn.notified = cl
+	return nil
+}
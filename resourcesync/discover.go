@@ -0,0 +1,77 @@
+package resourcesync
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// SourceCapability is a capability, such as a resource list or change list, offered by a source
+type SourceCapability struct {
+	CapabilityList string
+	Capability     string
+	Loc            string
+}
+
+// SourceDescriptionURL is the well known location of the source description for the site target is on
+func SourceDescriptionURL(target string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(target))
+	if err != nil {
+		return "", fmt.Errorf("invalid target %q: %v", target, err)
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return "", fmt.Errorf("target %q is not an absolute URL", target)
+	}
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/" + SourceDescriptionFile}).String(), nil
+}
+
+// Discover finds the capabilities a source offers. The target may be a source description, a capability list or
+// the root of the site, in which case the source description is fetched from its well known location. Every
+// capability list the source description references is fetched and its capabilities returned in order.
+func (rs *ResourceSync) Discover(target string) ([]SourceCapability, error) {
+	u, err := url.Parse(strings.TrimSpace(target))
+	if err != nil {
+		return nil, fmt.Errorf("invalid target %q: %v", target, err)
+	}
+	if u.Path == "" || u.Path == "/" {
+		if target, err = SourceDescriptionURL(target); err != nil {
+			return nil, err
+		}
+	}
+	rd, err := rs.Process(target)
+	if err != nil {
+		return nil, err
+	}
+	switch rd.RType {
+	case Capability:
+		return listedCapabilities(target, rd), nil
+	case Description:
+		var found []SourceCapability
+		for _, ru := range rd.RL.URLSet {
+			loc := strings.TrimSpace(ru.Loc)
+			cl, err := rs.Process(loc)
+			if err != nil {
+				return nil, fmt.Errorf("capability list %q: %v", loc, err)
+			}
+			if cl.RType != Capability {
+				return nil, fmt.Errorf("%q is not a capability list", loc)
+			}
+			found = append(found, listedCapabilities(loc, cl)...)
+		}
+		return found, nil
+	default:
+		return nil, fmt.Errorf("%q is neither a source description nor a capability list", target)
+	}
+}
+
+func listedCapabilities(capabilityList string, rd *ResourceData) []SourceCapability {
+	found := make([]SourceCapability, 0, len(rd.RL.URLSet))
+	for _, ru := range rd.RL.URLSet {
+		found = append(found, SourceCapability{
+			CapabilityList: capabilityList,
+			Capability:     ru.RSMD.Capability,
+			Loc:            strings.TrimSpace(ru.Loc),
+		})
+	}
+	return found
+}
@@ -0,0 +1,58 @@
+package resourcesync
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nathj07/go-resourcesync/fetcher"
+)
+
+func TestDiscover(t *testing.T) {
+	src, out := publishTestDirs(t)
+	defer os.RemoveAll(src)
+	defer os.RemoveAll(out)
+	server := httptest.NewServer(http.FileServer(http.Dir(out)))
+	defer server.Close()
+
+	p := NewPublisher(server.URL, out)
+	require.Nil(t, p.PublishDir(src, "http://example.com/data"))
+	rs := New(&fetcher.BasicRSFetcher{})
+
+	exp := []SourceCapability{
+		{CapabilityList: server.URL + "/" + CapabilityListFile, Capability: "resourcelist", Loc: server.URL + "/" + ResourceListFile},
+	}
+	for _, target := range []string{server.URL, server.URL + "/", server.URL + "/" + SourceDescriptionFile, server.URL + "/" + CapabilityListFile} {
+		got, err := rs.Discover(target)
+		require.Nil(t, err, target)
+		assert.Equal(t, exp, got, target)
+	}
+
+	_, err := rs.Discover(server.URL + "/" + ResourceListFile)
+	assert.NotNil(t, err)
+}
+
+func TestSourceDescriptionURL(t *testing.T) {
+	testData := []struct {
+		target string
+		exp    string
+		expErr bool
+	}{
+		{target: "http://example.com", exp: "http://example.com/.well-known/resourcesync"},
+		{target: "https://example.com/some/page?q=1", exp: "https://example.com/.well-known/resourcesync"},
+		{target: "/relative", expErr: true},
+	}
+	for _, td := range testData {
+		got, err := SourceDescriptionURL(td.target)
+		if td.expErr {
+			assert.NotNil(t, err, td.target)
+			continue
+		}
+		require.Nil(t, err, td.target)
+		assert.Equal(t, td.exp, got, td.target)
+	}
+}
@@ -1,6 +1,10 @@
 // package resourcesync holds the main data structures and methods for reading a ResourceSync feed.
 // The main public functions allow you to either send in a ResourceSync feed URL - Process(). Or send in
 // []byte from a ResourceSync feed - Parse(). In both cases you get a ResourceData object back with the
-// parsed data available for further inspection and use.
+// parsed data available for further inspection and use. Walk visits every entry below an index, only passing on
+// those matching the given Filters, such as MIMEType or ModifiedBetween.
+//
+// The package can also publish ResourceSync documents. A Publisher walks a local directory, or any
+// ResourceIterator, and writes the resource lists, capability list and source description describing it.
 
 package resourcesync
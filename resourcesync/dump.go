@@ -0,0 +1,275 @@
+package resourcesync
+
+import (
+	"archive/zip"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxDumpSize is the default limit, in bytes of uncompressed content, for a single dump package
+const DefaultMaxDumpSize = 1 << 30
+
+// The file names used when writing dumps, relative to the Publisher OutDir
+const (
+	ResourceDumpFile = "resourcedump.xml"
+	ChangeDumpFile   = "changedump.xml"
+	// ManifestFile is the name of the manifest held at the top level of every dump package
+	ManifestFile = "manifest.xml"
+)
+
+// ContentOpener provides the content of a resource being written into a dump package.
+// The caller of the opener is responsible for closing the returned reader.
+type ContentOpener func(ru *ResourceURL) (io.ReadCloser, error)
+
+// DirOpener returns a ContentOpener reading resources from srcDir, where the resources were described with Locs
+// below srcBaseURL; this matches the resources produced by a DirIterator.
+func DirOpener(srcDir, srcBaseURL string) ContentOpener {
+	base := strings.TrimSuffix(srcBaseURL, "/") + "/"
+	return func(ru *ResourceURL) (io.ReadCloser, error) {
+		loc := strings.TrimSpace(ru.Loc)
+		if !strings.HasPrefix(loc, base) {
+			return nil, fmt.Errorf("resource %q is not below %q", loc, base)
+		}
+		rel, err := url.PathUnescape(strings.TrimPrefix(loc, base))
+		if err != nil {
+			return nil, err
+		}
+		return os.Open(filepath.Join(srcDir, filepath.FromSlash(path.Clean("/"+rel))))
+	}
+}
+
+// PublishDirDump packages every file under srcDir into a resource dump.
+// Each file is given a Loc made up of srcBaseURL and the path of the file relative to srcDir.
+func (p *Publisher) PublishDirDump(srcDir, srcBaseURL string) error {
+	it, err := NewDirIterator(srcDir, srcBaseURL)
+	if err != nil {
+		return err
+	}
+	return p.PublishResourceDump(it, DirOpener(srcDir, srcBaseURL))
+}
+
+// PublishResourceDump packages the resources into one or more zip files, each holding the content along with
+// a resourcedump-manifest describing it. A new package is started whenever the next resource would take a package
+// beyond MaxDumpSize. The resource dump listing the packages is written and added to the capability list.
+func (p *Publisher) PublishResourceDump(it ResourceIterator, open ContentOpener) error {
+	at := timestamp(time.Now())
+	packages, err := p.writeDumpPackages("resourcedump", it, open, RSMD{Capability: resourcedumpManifest, At: at})
+	if err != nil {
+		return err
+	}
+	rd := &ResourceList{
+		RSLink: []RSLN{{Rel: "up", Href: p.url(CapabilityListFile)}},
+		RSMD: RSMD{
+			Capability: resourceDump,
+			At:         at,
+			Completed:  timestamp(time.Now()),
+		},
+		URLSet: packages,
+	}
+	if err := p.writeDocument(ResourceDumpFile, rd); err != nil {
+		return err
+	}
+	return p.writeCapabilityList()
+}
+
+// PublishChangeDump packages the content of the resources in the change list, as produced by DiffResourceLists,
+// into one or more zip files each with a changedump-manifest. Deleted resources appear in the manifest without
+// any content. The change dump listing the packages is written and added to the capability list.
+func (p *Publisher) PublishChangeDump(cl *ResourceList, open ContentOpener) error {
+	period := RSMD{From: cl.RSMD.From, Until: cl.RSMD.Until}
+	manifestMD := period
+	manifestMD.Capability = changedumpManifest
+	packages, err := p.writeDumpPackages("changedump", NewSliceIterator(cl.URLSet), open, manifestMD)
+	if err != nil {
+		return err
+	}
+	for i := range packages {
+		packages[i].RSMD.From = period.From
+		packages[i].RSMD.Until = period.Until
+	}
+	cd := &ResourceList{
+		RSLink: []RSLN{{Rel: "up", Href: p.url(CapabilityListFile)}},
+		RSMD: RSMD{
+			Capability: changeDump,
+			From:       period.From,
+			Until:      period.Until,
+		},
+		URLSet: packages,
+	}
+	if err := p.writeDocument(ChangeDumpFile, cd); err != nil {
+		return err
+	}
+	return p.writeCapabilityList()
+}
+
+// writeDumpPackages writes the numbered zip packages, returning the dump entries describing them. Packages left over
+// from an earlier, larger, dump are removed.
+func (p *Publisher) writeDumpPackages(prefix string, it ResourceIterator, open ContentOpener, manifestMD RSMD) ([]ResourceURL, error) {
+	max := p.MaxDumpSize
+	if max <= 0 {
+		max = DefaultMaxDumpSize
+	}
+	var packages []ResourceURL
+	var pkg *dumpPackage
+	for {
+		ru, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		length, _ := strconv.ParseInt(ru.RSMD.Length, 10, 64)
+		if pkg != nil && pkg.size > 0 && pkg.size+length > max {
+			entry, err := p.closeDumpPackage(pkg)
+			if err != nil {
+				return nil, err
+			}
+			packages = append(packages, entry)
+			pkg = nil
+		}
+		if pkg == nil {
+			name := fmt.Sprintf("%s_%04d.zip", prefix, len(packages))
+			if pkg, err = p.newDumpPackage(name, manifestMD); err != nil {
+				return nil, err
+			}
+		}
+		if err := pkg.add(ru, open); err != nil {
+			pkg.abort()
+			return nil, err
+		}
+	}
+	if pkg != nil {
+		entry, err := p.closeDumpPackage(pkg)
+		if err != nil {
+			return nil, err
+		}
+		packages = append(packages, entry)
+	}
+	if err := p.removeNumbered(prefix, ".zip", len(packages)); err != nil {
+		return nil, err
+	}
+	return packages, nil
+}
+
+// dumpPackage is a single zip file being written as part of a dump
+type dumpPackage struct {
+	name     string
+	file     *os.File
+	zw       *zip.Writer
+	manifest *ResourceList
+	paths    map[string]bool
+	size     int64
+}
+
+func (p *Publisher) newDumpPackage(name string, manifestMD RSMD) (*dumpPackage, error) {
+	if err := os.MkdirAll(p.OutDir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(filepath.Join(p.OutDir, name))
+	if err != nil {
+		return nil, err
+	}
+	return &dumpPackage{
+		name:     name,
+		file:     f,
+		zw:       zip.NewWriter(f),
+		manifest: &ResourceList{RSMD: manifestMD},
+		paths:    map[string]bool{},
+	}, nil
+}
+
+// add copies the content of the resource into the package, recording the computed hash and length in the manifest.
+// Deleted resources are recorded in the manifest only.
+func (pkg *dumpPackage) add(ru *ResourceURL, open ContentOpener) error {
+	entry := *ru
+	entry.Loc = strings.TrimSpace(entry.Loc)
+	if entry.RSMD.Change == ChangeDeleted {
+		pkg.manifest.URLSet = append(pkg.manifest.URLSet, entry)
+		return nil
+	}
+	rc, err := open(ru)
+	if err != nil {
+		return fmt.Errorf("failed to open content for %q: %v", entry.Loc, err)
+	}
+	defer rc.Close()
+	entry.RSMD.Path = pkg.contentPath(entry.Loc)
+	w, err := pkg.zw.Create(strings.TrimPrefix(entry.RSMD.Path, "/"))
+	if err != nil {
+		return err
+	}
+	h := md5.New()
+	n, err := io.Copy(io.MultiWriter(w, h), rc)
+	if err != nil {
+		return fmt.Errorf("failed to write content for %q: %v", entry.Loc, err)
+	}
+	entry.RSMD.Hash = "md5:" + hex.EncodeToString(h.Sum(nil))
+	entry.RSMD.Length = strconv.FormatInt(n, 10)
+	pkg.size += n
+	pkg.manifest.URLSet = append(pkg.manifest.URLSet, entry)
+	return nil
+}
+
+// contentPath derives the path of the content within the package from the path of its Loc
+func (pkg *dumpPackage) contentPath(loc string) string {
+	p := "/" + loc
+	if u, err := url.Parse(loc); err == nil && u.Path != "" {
+		p = u.Path
+	}
+	base := path.Clean("/resources/" + p)
+	p = base
+	for i := 2; pkg.paths[p]; i++ {
+		p = fmt.Sprintf("%s.%d", base, i)
+	}
+	pkg.paths[p] = true
+	return p
+}
+
+func (pkg *dumpPackage) abort() {
+	pkg.zw.Close()
+	pkg.file.Close()
+	os.Remove(pkg.file.Name())
+}
+
+// closeDumpPackage writes the manifest, finishes the zip and describes the package for the dump list
+func (p *Publisher) closeDumpPackage(pkg *dumpPackage) (ResourceURL, error) {
+	pkg.manifest.RSMD.Completed = timestamp(time.Now())
+	data, err := Marshal(pkg.manifest)
+	if err != nil {
+		pkg.abort()
+		return ResourceURL{}, err
+	}
+	w, err := pkg.zw.Create(ManifestFile)
+	if err != nil {
+		pkg.abort()
+		return ResourceURL{}, err
+	}
+	if _, err := w.Write(data); err != nil {
+		pkg.abort()
+		return ResourceURL{}, err
+	}
+	if err := pkg.zw.Close(); err != nil {
+		pkg.abort()
+		return ResourceURL{}, err
+	}
+	if err := pkg.file.Close(); err != nil {
+		return ResourceURL{}, err
+	}
+	ru, err := ResourceFromFile(pkg.file.Name(), p.url(pkg.name))
+	if err != nil {
+		return ResourceURL{}, err
+	}
+	ru.LastMod = ""
+	ru.RSMD.At = pkg.manifest.RSMD.At
+	ru.RSMD.Completed = pkg.manifest.RSMD.Completed
+	return *ru, nil
+}
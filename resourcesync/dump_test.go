@@ -0,0 +1,131 @@
+package resourcesync
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishDirDump(t *testing.T) {
+	src, out := publishTestDirs(t)
+	defer os.RemoveAll(src)
+	defer os.RemoveAll(out)
+
+	p := NewPublisher(testPublishBase, out)
+	p.MaxDumpSize = 10 // a.txt and b.json together exceed this, forcing a second package
+	require.Nil(t, p.PublishDirDump(src, "http://example.com/data"))
+
+	rd := parseFile(t, filepath.Join(out, ResourceDumpFile))
+	assert.Equal(t, ResourceDump, rd.RType)
+	require.Len(t, rd.RL.URLSet, 3)
+	assert.Equal(t, testPublishBase+"/resourcedump_0000.zip", rd.RL.URLSet[0].Loc)
+	assert.Equal(t, "application/zip", rd.RL.URLSet[0].RSMD.Type)
+	assert.True(t, strings.HasPrefix(rd.RL.URLSet[0].RSMD.Hash, "md5:"))
+
+	manifest, files := readDumpPackage(t, filepath.Join(out, "resourcedump_0000.zip"))
+	assert.Equal(t, ResourceDumpManifest, manifest.RType)
+	require.Len(t, manifest.RL.URLSet, 1)
+	entry := manifest.RL.URLSet[0]
+	assert.Equal(t, "http://example.com/data/a.txt", entry.Loc)
+	assert.Equal(t, "/resources/data/a.txt", entry.RSMD.Path)
+	assert.Equal(t, "md5:5d41402abc4b2a76b9719d911017c592", entry.RSMD.Hash)
+	assert.Equal(t, "5", entry.RSMD.Length)
+	assert.Equal(t, "hello", files["resources/data/a.txt"])
+
+	manifest, files = readDumpPackage(t, filepath.Join(out, "resourcedump_0002.zip"))
+	require.Len(t, manifest.RL.URLSet, 1)
+	assert.Equal(t, "/resources/data/sub dir/c.pdf", manifest.RL.URLSet[0].RSMD.Path)
+	assert.Equal(t, "%PDF-1.4", files["resources/data/sub dir/c.pdf"])
+
+	cl := parseFile(t, filepath.Join(out, CapabilityListFile))
+	require.Len(t, cl.RL.URLSet, 1)
+	assert.Equal(t, ResourceURL{Loc: testPublishBase + "/resourcedump.xml", RSMD: RSMD{Capability: "resourcedump"}}, cl.RL.URLSet[0])
+}
+
+func TestPublishDirDumpRemovesStalePackages(t *testing.T) {
+	src, out := publishTestDirs(t)
+	defer os.RemoveAll(src)
+	defer os.RemoveAll(out)
+
+	p := NewPublisher(testPublishBase, out)
+	p.MaxDumpSize = 10
+	require.Nil(t, p.PublishDirDump(src, "http://example.com/data"))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(out, "changedump_0000.zip"), []byte("other dump"), 0644))
+
+	// everything now fits in one package, the second and third are no longer part of the dump
+	p.MaxDumpSize = 0
+	require.Nil(t, p.PublishDirDump(src, "http://example.com/data"))
+	rd := parseFile(t, filepath.Join(out, ResourceDumpFile))
+	require.Len(t, rd.RL.URLSet, 1)
+	names, err := filepath.Glob(filepath.Join(out, "*.zip"))
+	require.Nil(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(out, "changedump_0000.zip"),
+		filepath.Join(out, "resourcedump_0000.zip"),
+	}, names)
+}
+
+func TestPublishChangeDump(t *testing.T) {
+	src, out := publishTestDirs(t)
+	defer os.RemoveAll(src)
+	defer os.RemoveAll(out)
+
+	cl := &ResourceList{
+		RSMD: RSMD{Capability: "changelist", From: "2020-01-01T00:00:00Z", Until: "2020-01-08T00:00:00Z"},
+		URLSet: []ResourceURL{
+			{Loc: "http://example.com/data/a.txt", RSMD: RSMD{Change: ChangeUpdated, DateTime: "2020-01-02T00:00:00Z"}},
+			{Loc: "http://example.com/data/old.txt", RSMD: RSMD{Change: ChangeDeleted, DateTime: "2020-01-08T00:00:00Z"}},
+		},
+	}
+	p := NewPublisher(testPublishBase, out)
+	require.Nil(t, p.PublishChangeDump(cl, DirOpener(src, "http://example.com/data")))
+
+	cd := parseFile(t, filepath.Join(out, ChangeDumpFile))
+	assert.Equal(t, ChangeDump, cd.RType)
+	assert.Equal(t, "2020-01-01T00:00:00Z", cd.RL.RSMD.From)
+	require.Len(t, cd.RL.URLSet, 1)
+	assert.Equal(t, "2020-01-08T00:00:00Z", cd.RL.URLSet[0].RSMD.Until)
+
+	manifest, files := readDumpPackage(t, filepath.Join(out, "changedump_0000.zip"))
+	assert.Equal(t, ChangeDumpManifest, manifest.RType)
+	require.Len(t, manifest.RL.URLSet, 2)
+	assert.Equal(t, ChangeUpdated, manifest.RL.URLSet[0].RSMD.Change)
+	assert.Equal(t, "/resources/data/a.txt", manifest.RL.URLSet[0].RSMD.Path)
+	assert.Equal(t, RSMD{Change: ChangeDeleted, DateTime: "2020-01-08T00:00:00Z"}, manifest.RL.URLSet[1].RSMD)
+	assert.Len(t, files, 1)
+}
+
+func TestDirOpenerOutsideBase(t *testing.T) {
+	_, err := DirOpener("/tmp", "http://example.com/data")(&ResourceURL{Loc: "http://other.com/a.txt"})
+	assert.NotNil(t, err)
+}
+
+// readDumpPackage returns the parsed manifest and the content of the other files in the zip
+func readDumpPackage(t *testing.T, name string) (*ResourceData, map[string]string) {
+	zr, err := zip.OpenReader(name)
+	require.Nil(t, err)
+	defer zr.Close()
+	var manifest *ResourceData
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.Nil(t, err)
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		require.Nil(t, err)
+		if f.Name == ManifestFile {
+			manifest, err = (&ResourceSync{}).Parse(data)
+			require.Nil(t, err)
+			continue
+		}
+		files[f.Name] = string(data)
+	}
+	require.NotNil(t, manifest)
+	return manifest, files
+}
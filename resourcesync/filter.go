@@ -0,0 +1,155 @@
+package resourcesync
+
+import (
+	"mime"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter reports if an entry of a resource list or change list should be visited. Filters are passed to Walk, and
+// the APIs built on it, to skip resources that are not wanted before they are fetched. They are composed with All,
+// Any and Not; several filters passed together must all match.
+type Filter func(ru ResourceURL) bool
+
+// All matches entries matched by every one of the filters, with no filters everything matches
+func All(filters ...Filter) Filter {
+	return func(ru ResourceURL) bool {
+		for _, f := range filters {
+			if !f(ru) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any matches entries matched by at least one of the filters
+func Any(filters ...Filter) Filter {
+	return func(ru ResourceURL) bool {
+		for _, f := range filters {
+			if f(ru) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches the entries the filter does not
+func Not(f Filter) Filter {
+	return func(ru ResourceURL) bool {
+		return !f(ru)
+	}
+}
+
+// MIMEType matches entries whose rs:md type is one of the given media types. Parameters such as charset are
+// ignored and a type may end in a wildcard, "text/*" matching any text type. Entries without a type do not match.
+func MIMEType(types ...string) Filter {
+	wanted := make([]string, len(types))
+	for i, t := range types {
+		wanted[i] = mediaType(t)
+	}
+	return func(ru ResourceURL) bool {
+		got := mediaType(ru.RSMD.Type)
+		if got == "" {
+			return false
+		}
+		for _, w := range wanted {
+			if w == got || w == "*/*" || (strings.HasSuffix(w, "/*") && strings.HasPrefix(got, strings.TrimSuffix(w, "*"))) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ModifiedBetween matches entries changed between from and until inclusive, using the rs:md datetime of change list
+// entries and the lastmod of resource list entries. A zero from or until leaves that end of the range open.
+// Entries without a valid datetime do not match.
+func ModifiedBetween(from, until time.Time) Filter {
+	return func(ru ResourceURL) bool {
+		value := ru.RSMD.DateTime
+		if value == "" {
+			value = ru.LastMod
+		}
+		at, err := ParseDateTime(value)
+		if err != nil {
+			return false
+		}
+		return (from.IsZero() || !at.Before(from)) && (until.IsZero() || !at.After(until))
+	}
+}
+
+// URLPrefix matches entries whose Loc starts with one of the prefixes
+func URLPrefix(prefixes ...string) Filter {
+	return func(ru ResourceURL) bool {
+		loc := strings.TrimSpace(ru.Loc)
+		for _, p := range prefixes {
+			if strings.HasPrefix(loc, p) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// URLPattern matches entries whose Loc matches the regular expression
+func URLPattern(re *regexp.Regexp) Filter {
+	return func(ru ResourceURL) bool {
+		return re.MatchString(strings.TrimSpace(ru.Loc))
+	}
+}
+
+// LengthBetween matches entries whose rs:md length is between min and max bytes inclusive, a max of zero or less
+// leaves the range open. Entries without a valid length do not match.
+func LengthBetween(min, max int64) Filter {
+	return func(ru ResourceURL) bool {
+		length, err := strconv.ParseInt(strings.TrimSpace(ru.RSMD.Length), 10, 64)
+		if err != nil {
+			return false
+		}
+		return length >= min && (max <= 0 || length <= max)
+	}
+}
+
+// IndexFilter reports if a list referenced by an index should be fetched and walked. Index filters prune whole lists
+// before they are fetched, where a Filter only skips the entries of lists already fetched; they are set as the
+// IndexFilters of a ResourceSync.
+type IndexFilter func(index IndexDef) bool
+
+// IndexURLPrefix matches index entries whose Loc starts with one of the prefixes
+func IndexURLPrefix(prefixes ...string) IndexFilter {
+	return indexFilter(URLPrefix(prefixes...))
+}
+
+// IndexURLPattern matches index entries whose Loc matches the regular expression
+func IndexURLPattern(re *regexp.Regexp) IndexFilter {
+	return indexFilter(URLPattern(re))
+}
+
+// IndexMIMEType matches index entries whose rs:md type is one of the given media types, as MIMEType does. The type
+// is optional on an index entry, so entries without one always match and are walked.
+func IndexMIMEType(types ...string) IndexFilter {
+	match := MIMEType(types...)
+	return func(index IndexDef) bool {
+		return strings.TrimSpace(index.RSMD.Type) == "" || match(ResourceURL{RSMD: index.RSMD})
+	}
+}
+
+// indexFilter applies a Filter to an index entry as if it were an entry of a list
+func indexFilter(f Filter) IndexFilter {
+	return func(index IndexDef) bool {
+		return f(ResourceURL{Loc: index.Loc, LastMod: index.LastMod, RSMD: index.RSMD})
+	}
+}
+
+// mediaType normalises a MIME type for comparison, dropping any parameters
+func mediaType(t string) string {
+	t = strings.TrimSpace(t)
+	if mt, _, err := mime.ParseMediaType(t); err == nil {
+		return mt
+	}
+	return strings.ToLower(strings.TrimSpace(strings.SplitN(t, ";", 2)[0]))
+}
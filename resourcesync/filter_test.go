@@ -0,0 +1,91 @@
+package resourcesync
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilters(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC)
+	testData := []struct {
+		tag    string
+		filter Filter
+		exp    []string
+	}{
+		{tag: "mime type", filter: MIMEType("application/pdf"), exp: []string{"pdf"}},
+		{tag: "mime type with parameters", filter: MIMEType("application/json; charset=utf-8"), exp: []string{"json"}},
+		{tag: "mime wildcard", filter: MIMEType("application/*"), exp: []string{"pdf", "json"}},
+		{tag: "several mime types", filter: MIMEType("text/html", "application/pdf"), exp: []string{"pdf", "html"}},
+		{tag: "modified between", filter: ModifiedBetween(from, until), exp: []string{"pdf", "change"}},
+		{tag: "modified since", filter: ModifiedBetween(from, time.Time{}), exp: []string{"pdf", "json", "change"}},
+		{tag: "url prefix", filter: URLPrefix("http://example.com/pdf/", "http://example.com/html/"), exp: []string{"pdf", "html"}},
+		{tag: "url pattern", filter: URLPattern(regexp.MustCompile(`\.json$`)), exp: []string{"json"}},
+		{tag: "min length", filter: LengthBetween(100, 0), exp: []string{"pdf", "json"}},
+		{tag: "length range", filter: LengthBetween(10, 200), exp: []string{"html", "pdf"}},
+		{tag: "all", filter: All(MIMEType("application/*"), LengthBetween(0, 500)), exp: []string{"pdf"}},
+		{tag: "all of nothing", filter: All(), exp: []string{"pdf", "json", "html", "change"}},
+		{tag: "any", filter: Any(MIMEType("text/html"), URLPattern(regexp.MustCompile(`change`))), exp: []string{"html", "change"}},
+		{tag: "not", filter: Not(MIMEType("application/pdf")), exp: []string{"json", "html", "change"}},
+	}
+	for _, td := range testData {
+		var got []string
+		for _, e := range filterEntries {
+			if td.filter(e.ru) {
+				got = append(got, e.name)
+			}
+		}
+		assert.ElementsMatch(t, td.exp, got, td.tag)
+	}
+}
+
+func TestIndexFilters(t *testing.T) {
+	pdfs := IndexDef{Loc: "http://example.com/rs/pdf/resourcelist_0000.xml", RSMD: RSMD{Type: "application/pdf"}}
+	metadata := IndexDef{Loc: "http://example.com/rs/metadata/resourcelist_0000.xml", RSMD: RSMD{Type: "application/json"}}
+	untyped := IndexDef{Loc: "http://example.com/rs/other/resourcelist_0000.xml"}
+	testData := []struct {
+		tag    string
+		filter IndexFilter
+		exp    []bool // whether pdfs, metadata and untyped match
+	}{
+		{tag: "url prefix", filter: IndexURLPrefix("http://example.com/rs/pdf/"), exp: []bool{true, false, false}},
+		{tag: "url pattern", filter: IndexURLPattern(regexp.MustCompile(`/(pdf|other)/`)), exp: []bool{true, false, true}},
+		{tag: "mime type", filter: IndexMIMEType("application/*"), exp: []bool{true, true, true}},
+		{tag: "mime type, untyped kept", filter: IndexMIMEType("application/pdf"), exp: []bool{true, false, true}},
+	}
+	for _, td := range testData {
+		assert.Equal(t, td.exp, []bool{td.filter(pdfs), td.filter(metadata), td.filter(untyped)}, td.tag)
+	}
+}
+
+//
+// Test Data
+//
+
+var filterEntries = []struct {
+	name string
+	ru   ResourceURL
+}{
+	{name: "pdf", ru: ResourceURL{
+		Loc:     "http://example.com/pdf/1.pdf",
+		LastMod: "2019-06-01T10:00:00Z",
+		RSMD:    RSMD{Type: "application/pdf", Length: "150"},
+	}},
+	{name: "json", ru: ResourceURL{
+		Loc:     "http://example.com/json/1.json",
+		LastMod: "2020-02-01",
+		RSMD:    RSMD{Type: "Application/JSON", Length: "2048"},
+	}},
+	{name: "html", ru: ResourceURL{
+		Loc:     "http://example.com/html/1",
+		LastMod: "2018-05-01T10:00:00",
+		RSMD:    RSMD{Type: "text/html; charset=utf-8", Length: "20"},
+	}},
+	{name: "change", ru: ResourceURL{
+		Loc:  "http://example.com/change/1",
+		RSMD: RSMD{Change: ChangeUpdated, DateTime: "2019-03-01T00:00:00Z"},
+	}},
+}
@@ -0,0 +1,79 @@
+package resourcesync
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+// hashAlgorithms maps the algorithm names used in the rs:md hash attribute to their implementations,
+// strongest first so it can also be used to pick the preferred hash.
+var hashAlgorithms = []struct {
+	name string
+	new  func() hash.Hash
+}{
+	{"sha-256", sha256.New},
+	{"sha-1", sha1.New},
+	{"md5", md5.New},
+}
+
+// PreferredHash picks the strongest supported hash from an rs:md hash attribute, which may list several
+// space separated values such as "md5:1e0d... sha-256:854f...". The returned value is in the same algorithm:digest
+// form; ok is false if none of the hashes use a supported algorithm.
+func PreferredHash(attr string) (value string, ok bool) {
+	for _, alg := range hashAlgorithms {
+		if value, ok := hashValue(attr, alg.name); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// commonHash picks the strongest supported algorithm found in both rs:md hash attributes, returning the value each
+// gives for it. ok is false if they have no supported algorithm in common.
+func commonHash(a, b string) (valueA, valueB string, ok bool) {
+	for _, alg := range hashAlgorithms {
+		valueA, okA := hashValue(a, alg.name)
+		valueB, okB := hashValue(b, alg.name)
+		if okA && okB {
+			return valueA, valueB, true
+		}
+	}
+	return "", "", false
+}
+
+// hashValue returns the hash using algorithm from an rs:md hash attribute, in lower case algorithm:digest form
+func hashValue(attr, algorithm string) (string, bool) {
+	for _, v := range strings.Fields(attr) {
+		if strings.HasPrefix(strings.ToLower(v), algorithm+":") {
+			return algorithm + ":" + strings.ToLower(v[len(algorithm)+1:]), true
+		}
+	}
+	return "", false
+}
+
+// HashAlgorithm returns the algorithm of a hash in algorithm:digest form
+func HashAlgorithm(value string) string {
+	return strings.ToLower(strings.SplitN(value, ":", 2)[0])
+}
+
+// ComputeHash reads r to the end, returning its hash in algorithm:digest form. The algorithm is either given on its
+// own, such as "md5", or taken from an expected hash value which is typically the result of PreferredHash.
+func ComputeHash(r io.Reader, expected string) (string, error) {
+	algorithm := HashAlgorithm(expected)
+	for _, alg := range hashAlgorithms {
+		if alg.name == algorithm {
+			h := alg.new()
+			if _, err := io.Copy(h, r); err != nil {
+				return "", err
+			}
+			return alg.name + ":" + hex.EncodeToString(h.Sum(nil)), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported hash %q", expected)
+}
@@ -0,0 +1,46 @@
+package resourcesync
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreferredHash(t *testing.T) {
+	type testData struct {
+		attr  string
+		exp   string
+		expOK bool
+	}
+	testTable := []testData{
+		{attr: "md5:d030c6d483b306029b0897630e67c550", exp: "md5:d030c6d483b306029b0897630e67c550", expOK: true},
+		{attr: "md5:AB sha-256:CD", exp: "sha-256:cd", expOK: true},
+		{attr: "sha-1:ef md5:ab", exp: "sha-1:ef", expOK: true},
+		{attr: "crc32:12345", expOK: false},
+		{attr: "", expOK: false},
+	}
+	for _, td := range testTable {
+		got, ok := PreferredHash(td.attr)
+		assert.Equal(t, td.expOK, ok, td.attr)
+		assert.Equal(t, td.exp, got, td.attr)
+	}
+}
+
+func TestComputeHash(t *testing.T) {
+	got, err := ComputeHash(strings.NewReader("hello"), "md5:anything")
+	require.Nil(t, err)
+	assert.Equal(t, "md5:5d41402abc4b2a76b9719d911017c592", got)
+
+	got, err = ComputeHash(strings.NewReader("hello"), "sha-256:anything")
+	require.Nil(t, err)
+	assert.Equal(t, "sha-256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", got)
+
+	got, err = ComputeHash(strings.NewReader("hello"), "sha-1")
+	require.Nil(t, err)
+	assert.Equal(t, "sha-1:aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", got)
+
+	_, err = ComputeHash(strings.NewReader("hello"), "crc32:anything")
+	assert.NotNil(t, err)
+}
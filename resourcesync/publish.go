@@ -0,0 +1,418 @@
+package resourcesync
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxListEntries is the maximum number of url entries the sitemap protocol allows in a single list.
+// Any more than this and the list must be split and referenced from an index.
+const MaxListEntries = 50000
+
+// The file names used when writing the published documents. These follow the layout used by the CORE publisher
+// feeds and are relative to the Publisher OutDir.
+const (
+	SourceDescriptionFile = ".well-known/resourcesync"
+	CapabilityListFile    = "capabilitylist.xml"
+	ResourceListFile      = "resourcelist.xml"
+	ResourceListIndexFile = "resourcelist-index.xml"
+	ChangeListFile        = "changelist.xml"
+)
+
+// ResourceIterator supplies the resources to be published one at a time.
+// Next returns io.EOF once there are no more resources.
+type ResourceIterator interface {
+	Next() (*ResourceURL, error)
+}
+
+// ChangeNotifier pushes published change lists to subscribers, see the notification package for a WebSub
+// implementation.
+type ChangeNotifier interface {
+	// NotificationChannel returns the hub and topic notifications are published to, these are advertised in the
+	// capability list so subscribers can find them.
+	NotificationChannel() (hub, topic string)
+	NotifyChanges(cl *ResourceList) error
+}
+
+// Publisher writes ResourceSync documents describing a set of resources.
+// The documents are written to OutDir and are expected to be served from BaseURL, which is used when building
+// the links between them. If a Notifier is set each published change list is also pushed to it.
+type Publisher struct {
+	BaseURL     string
+	OutDir      string
+	MaxEntries  int   // defaults to MaxListEntries if not set
+	MaxDumpSize int64 // defaults to DefaultMaxDumpSize if not set
+	Notifier    ChangeNotifier
+}
+
+// NewPublisher is the simplest way to instantiate a ready to use Publisher
+func NewPublisher(baseURL, outDir string) *Publisher {
+	return &Publisher{
+		BaseURL:     baseURL,
+		OutDir:      outDir,
+		MaxEntries:  MaxListEntries,
+		MaxDumpSize: DefaultMaxDumpSize,
+	}
+}
+
+// PublishDir describes every file under srcDir and publishes the resulting resource list.
+// Each file is given a Loc made up of srcBaseURL and the path of the file relative to srcDir.
+func (p *Publisher) PublishDir(srcDir, srcBaseURL string) error {
+	it, err := NewDirIterator(srcDir, srcBaseURL)
+	if err != nil {
+		return err
+	}
+	return p.Publish(it)
+}
+
+// Publish consumes the iterator, writing a resource list, or a resource list index and the lists it references
+// if there are more than MaxEntries resources. A capability list and source description linking to them are
+// also written.
+func (p *Publisher) Publish(it ResourceIterator) error {
+	max := p.MaxEntries
+	if max <= 0 {
+		max = MaxListEntries
+	}
+	at := timestamp(time.Now())
+	var lists []IndexDef
+	var pending *ResourceURL
+	for {
+		chunk := []ResourceURL{}
+		listAt := timestamp(time.Now())
+		if pending != nil {
+			chunk = append(chunk, *pending)
+			pending = nil
+		}
+		done := false
+		for len(chunk) < max {
+			ru, err := it.Next()
+			if err == io.EOF {
+				done = true
+				break
+			}
+			if err != nil {
+				return err
+			}
+			chunk = append(chunk, *ru)
+		}
+		if !done {
+			// peek ahead so we know whether this is the only list or the first of many
+			ru, err := it.Next()
+			switch {
+			case err == io.EOF:
+				done = true
+			case err != nil:
+				return err
+			default:
+				pending = ru
+			}
+		}
+		if done && len(lists) == 0 {
+			if err := p.writeResourceList(ResourceListFile, chunk, at, false); err != nil {
+				return err
+			}
+			if err := p.removeDocument(ResourceListIndexFile); err != nil {
+				return err
+			}
+			if err := p.removeNumbered("resourcelist", ".xml", 0); err != nil {
+				return err
+			}
+			return p.writeCapabilityList()
+		}
+		name := fmt.Sprintf("resourcelist_%04d.xml", len(lists))
+		if err := p.writeResourceList(name, chunk, listAt, true); err != nil {
+			return err
+		}
+		lists = append(lists, IndexDef{
+			Loc:  p.url(name),
+			RSMD: RSMD{At: listAt, Completed: timestamp(time.Now())},
+		})
+		if done {
+			break
+		}
+	}
+	rli := &ResourceListIndex{
+		RSLink: []RSLN{{Rel: "up", Href: p.url(CapabilityListFile)}},
+		RSMD: RSMD{
+			Capability: resourceList,
+			At:         at,
+			Completed:  timestamp(time.Now()),
+		},
+		IndexSet: lists,
+	}
+	if err := p.writeDocument(ResourceListIndexFile, rli); err != nil {
+		return err
+	}
+	if err := p.removeDocument(ResourceListFile); err != nil {
+		return err
+	}
+	if err := p.removeNumbered("resourcelist", ".xml", len(lists)); err != nil {
+		return err
+	}
+	return p.writeCapabilityList()
+}
+
+// PublishChangeList writes the change list, as produced by DiffResourceLists, alongside the resource list and adds
+// it to the capability list. Any previously published change list is replaced. Once written the changes are sent
+// to the Notifier, if there is one. cl itself is left unchanged, the links of the published copy are replaced by
+// one up to the capability list.
+func (p *Publisher) PublishChangeList(cl *ResourceList) error {
+	published := *cl
+	published.RSLink = []RSLN{{Rel: "up", Href: p.url(CapabilityListFile)}}
+	cl = &published
+	if err := p.writeDocument(ChangeListFile, cl); err != nil {
+		return err
+	}
+	if err := p.writeCapabilityList(); err != nil {
+		return err
+	}
+	if p.Notifier == nil {
+		return nil
+	}
+	if err := p.Notifier.NotifyChanges(cl); err != nil {
+		return fmt.Errorf("change list published but notification failed: %v", err)
+	}
+	return nil
+}
+
+func (p *Publisher) writeResourceList(name string, urls []ResourceURL, at string, indexed bool) error {
+	rl := &ResourceList{
+		RSLink: []RSLN{{Rel: "up", Href: p.url(CapabilityListFile)}},
+		RSMD: RSMD{
+			Capability: resourceList,
+			At:         at,
+			Completed:  timestamp(time.Now()),
+		},
+		URLSet: urls,
+	}
+	if indexed {
+		rl.RSLink = append(rl.RSLink, RSLN{Rel: "index", Href: p.url(ResourceListIndexFile)})
+	}
+	return p.writeDocument(name, rl)
+}
+
+// capabilities lists the documents that may be advertised in the capability list, in the order they appear
+var capabilities = []struct {
+	file       string
+	capability string
+}{
+	{ResourceListIndexFile, resourceList},
+	{ResourceListFile, resourceList},
+	{ResourceDumpFile, resourceDump},
+	{ChangeListFile, changeList},
+	{ChangeDumpFile, changeDump},
+}
+
+// writeCapabilityList writes the capability list pointing at each of the documents present in OutDir,
+// along with the source description pointing at the capability list.
+func (p *Publisher) writeCapabilityList() error {
+	cl := &ResourceList{
+		RSLink: []RSLN{{Rel: "up", Href: p.url(SourceDescriptionFile)}},
+		RSMD:   RSMD{Capability: capabilityList},
+	}
+	for _, c := range capabilities {
+		if _, err := os.Stat(filepath.Join(p.OutDir, filepath.FromSlash(c.file))); err != nil {
+			continue
+		}
+		cl.URLSet = append(cl.URLSet, ResourceURL{Loc: p.url(c.file), RSMD: RSMD{Capability: c.capability}})
+	}
+	if p.Notifier != nil {
+		hub, topic := p.Notifier.NotificationChannel()
+		cl.URLSet = append(cl.URLSet, ResourceURL{
+			Loc:  topic,
+			RSMD: RSMD{Capability: changeListNotification},
+			RSLN: RSLN{Rel: "hub", Href: hub},
+		})
+	}
+	if err := p.writeDocument(CapabilityListFile, cl); err != nil {
+		return err
+	}
+	sd := &ResourceList{
+		RSMD: RSMD{Capability: description},
+		URLSet: []ResourceURL{
+			{Loc: p.url(CapabilityListFile), RSMD: RSMD{Capability: capabilityList}},
+		},
+	}
+	return p.writeDocument(SourceDescriptionFile, sd)
+}
+
+func (p *Publisher) writeDocument(name string, doc interface{}) error {
+	dest := filepath.Join(p.OutDir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	data, err := Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %q: %v", name, err)
+	}
+	return ioutil.WriteFile(dest, data, 0644)
+}
+
+func (p *Publisher) removeDocument(name string) error {
+	err := os.Remove(filepath.Join(p.OutDir, filepath.FromSlash(name)))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// removeNumbered removes the numbered files, such as the lists of an earlier index or the packages of an earlier
+// dump, from the keep'th on, so that publishing fewer of them does not leave stale ones behind
+func (p *Publisher) removeNumbered(prefix, ext string, keep int) error {
+	names, err := filepath.Glob(filepath.Join(p.OutDir, prefix+"_*"+ext))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		var n int
+		if _, err := fmt.Sscanf(filepath.Base(name), prefix+"_%d"+ext, &n); err != nil || n < keep {
+			continue
+		}
+		if err := p.removeDocument(filepath.Base(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// url builds the absolute URL of a published document
+func (p *Publisher) url(name string) string {
+	return strings.TrimSuffix(p.BaseURL, "/") + "/" + name
+}
+
+// Marshal produces the XML document, including the XML header, for a ResourceList or ResourceListIndex
+func Marshal(doc interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// timestamp formats t as the W3C datetime used throughout the ResourceSync documents
+func timestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ResourceFromFile builds the ResourceURL for the local file at filePath, to be published at loc.
+// The hash, length and MIME type are computed from the file and the last modified time is taken from the file system.
+func ResourceFromFile(filePath, loc string) (*ResourceURL, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	h := md5.New()
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(f, sniff)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	sniff = sniff[:n]
+	h.Write(sniff)
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	mimeType := mime.TypeByExtension(filepath.Ext(filePath))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(sniff)
+	}
+	return &ResourceURL{
+		Loc:     loc,
+		LastMod: timestamp(info.ModTime()),
+		RSMD: RSMD{
+			Hash:   "md5:" + hex.EncodeToString(h.Sum(nil)),
+			Length: strconv.FormatInt(info.Size(), 10),
+			Type:   mimeType,
+		},
+	}, nil
+}
+
+// DirIterator is a ResourceIterator over the regular files in a local directory tree.
+// Files are only read, to compute their hashes, as they are returned from Next.
+type DirIterator struct {
+	dir     string
+	baseURL string
+	files   []string
+}
+
+// NewDirIterator lists the files below dir, ready to be described as resources located under baseURL
+func NewDirIterator(dir, baseURL string) (*DirIterator, error) {
+	di := &DirIterator{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			di.files = append(di.files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return di, nil
+}
+
+// Next describes the next file in the directory, returning io.EOF when all files have been visited
+func (di *DirIterator) Next() (*ResourceURL, error) {
+	if len(di.files) == 0 {
+		return nil, io.EOF
+	}
+	filePath := di.files[0]
+	di.files = di.files[1:]
+	rel, err := filepath.Rel(di.dir, filePath)
+	if err != nil {
+		return nil, err
+	}
+	return ResourceFromFile(filePath, di.baseURL+"/"+escapePath(filepath.ToSlash(rel)))
+}
+
+// SliceIterator is a ResourceIterator over resources already held in memory
+type SliceIterator struct {
+	urls []ResourceURL
+}
+
+// NewSliceIterator returns a ResourceIterator over the given resources
+func NewSliceIterator(urls []ResourceURL) *SliceIterator {
+	return &SliceIterator{urls: urls}
+}
+
+// Next returns the next resource, returning io.EOF when all have been returned
+func (si *SliceIterator) Next() (*ResourceURL, error) {
+	if len(si.urls) == 0 {
+		return nil, io.EOF
+	}
+	ru := si.urls[0]
+	si.urls = si.urls[1:]
+	return &ru, nil
+}
+
+// escapePath escapes each segment of a slash separated relative path for use in a URL
+func escapePath(rel string) string {
+	segments := strings.Split(rel, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return path.Join(segments...)
+}
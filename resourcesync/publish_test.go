@@ -0,0 +1,133 @@
+package resourcesync
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPublishBase = "http://example.com/rs"
+
+func TestPublishDirSingleList(t *testing.T) {
+	src, out := publishTestDirs(t)
+	defer os.RemoveAll(src)
+	defer os.RemoveAll(out)
+
+	p := NewPublisher(testPublishBase, out)
+	require.Nil(t, p.PublishDir(src, "http://example.com/data"))
+
+	rd := parseFile(t, filepath.Join(out, ResourceListFile))
+	assert.Equal(t, List, rd.RType)
+	assert.Equal(t, []RSLN{{Rel: "up", Href: testPublishBase + "/capabilitylist.xml"}}, rd.RL.RSLink)
+	require.Len(t, rd.RL.URLSet, 3)
+	first := rd.RL.URLSet[0]
+	assert.Equal(t, "http://example.com/data/a.txt", first.Loc)
+	assert.Equal(t, "md5:5d41402abc4b2a76b9719d911017c592", first.RSMD.Hash)
+	assert.Equal(t, "5", first.RSMD.Length)
+	assert.Equal(t, "text/plain; charset=utf-8", first.RSMD.Type)
+	assert.Equal(t, "http://example.com/data/sub%20dir/c.pdf", rd.RL.URLSet[2].Loc)
+	assert.Equal(t, "application/pdf", rd.RL.URLSet[2].RSMD.Type)
+
+	cl := parseFile(t, filepath.Join(out, CapabilityListFile))
+	assert.Equal(t, Capability, cl.RType)
+	assert.Equal(t, testPublishBase+"/resourcelist.xml", cl.RL.URLSet[0].Loc)
+
+	sd := parseFile(t, filepath.Join(out, filepath.FromSlash(SourceDescriptionFile)))
+	assert.Equal(t, Description, sd.RType)
+	assert.Equal(t, testPublishBase+"/capabilitylist.xml", sd.RL.URLSet[0].Loc)
+}
+
+func TestPublishSplitsIntoIndex(t *testing.T) {
+	src, out := publishTestDirs(t)
+	defer os.RemoveAll(src)
+	defer os.RemoveAll(out)
+
+	p := NewPublisher(testPublishBase, out)
+	p.MaxEntries = 2
+	require.Nil(t, p.PublishDir(src, "http://example.com/data"))
+
+	rd := parseFile(t, filepath.Join(out, ResourceListIndexFile))
+	assert.Equal(t, Index, rd.RType)
+	require.Len(t, rd.RLI.IndexSet, 2)
+	assert.Equal(t, testPublishBase+"/resourcelist_0000.xml", rd.RLI.IndexSet[0].Loc)
+	assert.Equal(t, testPublishBase+"/resourcelist_0001.xml", rd.RLI.IndexSet[1].Loc)
+
+	first := parseFile(t, filepath.Join(out, "resourcelist_0000.xml"))
+	assert.Len(t, first.RL.URLSet, 2)
+	assert.Contains(t, first.RL.RSLink, RSLN{Rel: "index", Href: testPublishBase + "/resourcelist-index.xml"})
+	second := parseFile(t, filepath.Join(out, "resourcelist_0001.xml"))
+	assert.Len(t, second.RL.URLSet, 1)
+
+	cl := parseFile(t, filepath.Join(out, CapabilityListFile))
+	assert.Equal(t, testPublishBase+"/resourcelist-index.xml", cl.RL.URLSet[0].Loc)
+	_, err := os.Stat(filepath.Join(out, ResourceListFile))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPublishRemovesStaleLists(t *testing.T) {
+	out, err := ioutil.TempDir("", "rs-publish-out")
+	require.Nil(t, err)
+	defer os.RemoveAll(out)
+	urls := []ResourceURL{{Loc: "http://example.com/1"}, {Loc: "http://example.com/2"}, {Loc: "http://example.com/3"}}
+	listed := func() []string {
+		names, err := filepath.Glob(filepath.Join(out, "resourcelist*.xml"))
+		require.Nil(t, err)
+		for i, name := range names {
+			names[i] = filepath.Base(name)
+		}
+		return names
+	}
+
+	p := NewPublisher(testPublishBase, out)
+	p.MaxEntries = 1
+	require.Nil(t, p.Publish(NewSliceIterator(urls)))
+	assert.Equal(t, []string{"resourcelist-index.xml", "resourcelist_0000.xml", "resourcelist_0001.xml",
+		"resourcelist_0002.xml"}, listed())
+
+	p.MaxEntries = 2
+	require.Nil(t, p.Publish(NewSliceIterator(urls)))
+	assert.Equal(t, []string{"resourcelist-index.xml", "resourcelist_0000.xml", "resourcelist_0001.xml"}, listed(),
+		"a smaller index")
+
+	p.MaxEntries = 0
+	require.Nil(t, p.Publish(NewSliceIterator(urls)))
+	assert.Equal(t, []string{"resourcelist.xml"}, listed(), "back to a single list")
+}
+
+func TestPublishExactlyMaxEntries(t *testing.T) {
+	out, err := ioutil.TempDir("", "rs-publish-out")
+	require.Nil(t, err)
+	defer os.RemoveAll(out)
+
+	p := NewPublisher(testPublishBase, out)
+	p.MaxEntries = 2
+	it := NewSliceIterator([]ResourceURL{{Loc: "http://example.com/1"}, {Loc: "http://example.com/2"}})
+	require.Nil(t, p.Publish(it))
+
+	rd := parseFile(t, filepath.Join(out, ResourceListFile))
+	assert.Len(t, rd.RL.URLSet, 2)
+}
+
+func publishTestDirs(t *testing.T) (string, string) {
+	src, err := ioutil.TempDir("", "rs-publish-src")
+	require.Nil(t, err)
+	out, err := ioutil.TempDir("", "rs-publish-out")
+	require.Nil(t, err)
+	require.Nil(t, os.MkdirAll(filepath.Join(src, "sub dir"), 0755))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(src, "a.txt"), []byte("hello"), 0644))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(src, "b.json"), []byte(`{"id":1}`), 0644))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(src, "sub dir", "c.pdf"), []byte("%PDF-1.4"), 0644))
+	return src, out
+}
+
+func parseFile(t *testing.T, name string) *ResourceData {
+	data, err := ioutil.ReadFile(name)
+	require.Nil(t, err)
+	rd, err := (&ResourceSync{}).Parse(data)
+	require.Nil(t, err)
+	return rd
+}
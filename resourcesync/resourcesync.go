@@ -27,25 +27,60 @@ const (
 	ResourceDumpManifest
 	// ChangeDumpManifest indicates this is the manifest data from a changedump - a weekly generate dump of CORE data
 	ChangeDumpManifest
+	// Description indicates this is a source description, the entry point advertised at /.well-known/resourcesync
+	Description
+	// ResourceDump indicates this is a resource dump, listing the packages that make up a bulk export
+	ResourceDump
+	// ChangeDump indicates this is a change dump, listing the packages of changed resources
+	ChangeDump
+	// ResourceListNotification indicates this is a resource list change notification payload
+	ResourceListNotification
+	// ChangeListNotification indicates this is a change list change notification payload
+	ChangeListNotification
+	// ResourceListArchive indicates this is a resource list archive, listing the historical resource lists
+	ResourceListArchive
+	// ResourceListArchiveIndex indicates this is an index of resource list archives
+	ResourceListArchiveIndex
+	// ChangeListArchive indicates this is a change list archive, listing the historical change lists
+	ChangeListArchive
+	// ChangeListArchiveIndex indicates this is an index of change list archives
+	ChangeListArchiveIndex
 )
 
 // These constants are correctly formatted strings that help to determine feed types
 const (
+	description    = "description"
 	capabilityList = "capabilitylist"
 	resourceList   = "resourcelist"
 	changeList     = "changelist"
+	resourceDump   = "resourcedump"
+	changeDump     = "changedump"
+	// the notification capabilities appear on the payloads pushed to subscribers, and in the capability list to
+	// advertise the notification channels
+	resourceListNotification = "resourcelist-notification"
+	changeListNotification   = "changelist-notification"
+	resourceListArchive      = "resourcelist-archive"
+	changeListArchive        = "changelist-archive"
 	// the following are specific to the CORE fastsync. It is an xml file within the retrieved zip file that details
 	// the relative local path for the unpacked items. Other than an extra attribute it conforms to the same schema as a changelist.
 	resourcedumpManifest = "resourcedump-manifest"
 	changedumpManifest   = "changedump-manifest"
 )
 
+// The namespaces declared on the root element of every ResourceSync document
+const (
+	sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	rsNamespace      = "http://www.openarchives.org/rs/terms/"
+)
+
 // ErrUnsupportedFeedType is used when the feed type is not one of the supported set
 var ErrUnsupportedFeedType = errors.New("unsupported feed type supplied")
 
-// ResourceSync is the top level structure needed to interact with ResourceSync endpoints
+// ResourceSync is the top level structure needed to interact with ResourceSync endpoints. If IndexFilters are set
+// Walk only fetches the lists of an index matching all of them.
 type ResourceSync struct {
-	Fetcher fetcher.RSFetcher
+	Fetcher      fetcher.RSFetcher
+	IndexFilters []IndexFilter
 }
 
 // ResourceData is the structure for holding the data returned from a ResoureceSync fetch.
@@ -56,6 +91,17 @@ type ResourceData struct {
 	RType int // based on the type const above
 }
 
+// Metadata returns the top level md of whichever of RL or RLI is populated
+func (rd *ResourceData) Metadata() RSMD {
+	if rd.RLI != nil {
+		return rd.RLI.RSMD
+	}
+	if rd.RL != nil {
+		return rd.RL.RSMD
+	}
+	return RSMD{}
+}
+
 // New is the simplest way to instantiate a ready to use ResourceSync object
 func New(f fetcher.RSFetcher) *ResourceSync {
 	return &ResourceSync{
@@ -104,6 +150,10 @@ func (rs *ResourceSync) parseIndexType(feed []byte) (*ResourceData, error) {
 		rd.RType = ChangeListIndex
 	case resourceList:
 		rd.RType = Index
+	case resourceListArchive:
+		rd.RType = ResourceListArchiveIndex
+	case changeListArchive:
+		rd.RType = ChangeListArchiveIndex
 	default:
 		return nil, ErrUnsupportedFeedType
 	}
@@ -123,8 +173,22 @@ func (rs *ResourceSync) parseListType(feed []byte) (*ResourceData, error) {
 		rd.RType = List
 	case capabilityList:
 		rd.RType = Capability
+	case description:
+		rd.RType = Description
 	case changeList:
 		rd.RType = ChangeList
+	case resourceDump:
+		rd.RType = ResourceDump
+	case changeDump:
+		rd.RType = ChangeDump
+	case resourceListArchive:
+		rd.RType = ResourceListArchive
+	case changeListArchive:
+		rd.RType = ChangeListArchive
+	case resourceListNotification:
+		rd.RType = ResourceListNotification
+	case changeListNotification:
+		rd.RType = ChangeListNotification
 	case resourcedumpManifest:
 		rd.RType = ResourceDumpManifest
 	case changedumpManifest:
@@ -116,6 +116,36 @@ func TestParse(t *testing.T) {
 	}
 }
 
+// TestParseLinks ensures every rs:ln of an entry is kept, the first as RSLN and the rest as Links
+func TestParseLinks(t *testing.T) {
+	rs := &ResourceSync{}
+	rd, err := rs.Parse([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:md capability="resourcelist"/>
+	<url>
+		<loc>http://example.com/1.pdf</loc>
+		<rs:ln rel="duplicate" href="http://mirror.example.com/1.pdf"/>
+		<rs:ln rel="describedBy" href="http://example.com/1.json" type="application/json"/>
+	</url>
+</urlset>`))
+	require.Nil(t, err)
+	require.Len(t, rd.RL.URLSet, 1)
+	ru := rd.RL.URLSet[0]
+	assert.Equal(t, RSLN{Rel: "duplicate", Href: "http://mirror.example.com/1.pdf"}, ru.RSLN)
+	assert.Equal(t, []RSLN{{Rel: "describedBy", Href: "http://example.com/1.json", Type: "application/json"}}, ru.Links)
+
+	ln, ok := ru.Link("describedby")
+	assert.True(t, ok)
+	assert.Equal(t, "http://example.com/1.json", ln.Href)
+	_, ok = ru.Link("hub")
+	assert.False(t, ok)
+
+	data, err := Marshal(rd.RL)
+	require.Nil(t, err)
+	back, err := rs.Parse(data)
+	require.Nil(t, err)
+	assert.Equal(t, ru, back.RL.URLSet[0], "both links are written")
+}
+
 func TestProcess(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, string(testResourceListIndex))
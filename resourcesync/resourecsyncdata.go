@@ -40,6 +40,13 @@ func (rli *ResourceListIndex) String() string {
 	return sb.String()
 }
 
+// MarshalXML writes the ResourceListIndex as a sitemapindex with the sitemap and ResourceSync namespaces declared,
+// allowing the output to be read back by Parse.
+func (rli *ResourceListIndex) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain ResourceListIndex // avoids recursing into this method
+	return e.EncodeElement((*plain)(rli), rootElement("sitemapindex"))
+}
+
 // ResourceList hods the data from a resource list
 type ResourceList struct {
 	XMLName xml.Name      `xml:"urlset"`
@@ -72,26 +79,98 @@ func (rl *ResourceList) String() string {
 	return sb.String()
 }
 
+// MarshalXML writes the ResourceList as a urlset with the sitemap and ResourceSync namespaces declared,
+// allowing the output to be read back by Parse.
+func (rl *ResourceList) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain ResourceList // avoids recursing into this method
+	return e.EncodeElement((*plain)(rl), rootElement("urlset"))
+}
+
+// rootElement builds the start element for a top level document, declaring the namespaces used throughout
+func rootElement(name string) xml.StartElement {
+	return xml.StartElement{
+		Name: xml.Name{Local: name},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "xmlns"}, Value: sitemapNamespace},
+			{Name: xml.Name{Local: "xmlns:rs"}, Value: rsNamespace},
+		},
+	}
+}
+
 // ResourceURL holds the data retrieved from the url tag set within a standard sitemap.xml
 type ResourceURL struct {
-	Loc        string `xml:"loc"`        // mandatory
-	LastMod    string `xml:"lastmod"`    // optional
-	ChangeFreq string `xml:"changefreq"` // optional
-	RSMD       RSMD   `xml:"md"`         // optional
-	RSLN       RSLN   `xml:"ln"`         // optional
+	Loc        string `xml:"loc"`                  // mandatory
+	LastMod    string `xml:"lastmod,omitempty"`    // optional
+	ChangeFreq string `xml:"changefreq,omitempty"` // optional
+	RSMD       RSMD   `xml:"md"`                   // optional
+	RSLN       RSLN   `xml:"ln"`                   // optional, the first link
+	Links      []RSLN `xml:"-"`                    // optional, any links after the first
 }
 
 // String implements the stringer interface for ResourceURL ensuring consistent printing of values
 func (ru ResourceURL) String() string {
+	linkTexts := []string{}
+	for _, ln := range ru.allLinks() {
+		linkTexts = append(linkTexts, ln.String())
+	}
 	return fmt.Sprintf("Loc: %s LastMod: %s ChangeFreq: %s RSMD: %s RSLN: %s",
-		ru.Loc, ru.LastMod, ru.ChangeFreq, ru.RSMD.String(), ru.RSLN.String())
+		ru.Loc, ru.LastMod, ru.ChangeFreq, ru.RSMD.String(), strings.Join(linkTexts, ", "))
+}
+
+// Link returns the first rs:ln of the entry with the given relation, which is matched ignoring case
+func (ru ResourceURL) Link(rel string) (RSLN, bool) {
+	for _, ln := range ru.allLinks() {
+		if strings.EqualFold(strings.TrimSpace(ln.Rel), rel) {
+			return ln, true
+		}
+	}
+	return RSLN{}, false
+}
+
+// allLinks returns RSLN, if set, followed by Links
+func (ru ResourceURL) allLinks() []RSLN {
+	if ru.RSLN == (RSLN{}) {
+		return ru.Links
+	}
+	return append([]RSLN{ru.RSLN}, ru.Links...)
+}
+
+// plainResourceURL has the fields of a ResourceURL without its methods. Embedded in resourceURLLinks its single ln
+// field gives way to the list of them.
+type plainResourceURL ResourceURL
+
+type resourceURLLinks struct {
+	plainResourceURL
+	Links []RSLN `xml:"ln"`
+}
+
+// MarshalXML writes the entry with RSLN followed by Links as its rs:ln elements
+func (ru ResourceURL) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(resourceURLLinks{plainResourceURL: plainResourceURL(ru), Links: ru.allLinks()}, start)
+}
+
+// UnmarshalXML reads the entry, keeping the first rs:ln as RSLN and the rest as Links
+func (ru *ResourceURL) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var all resourceURLLinks
+	if err := d.DecodeElement(&all, &start); err != nil {
+		return err
+	}
+	*ru = ResourceURL(all.plainResourceURL)
+	ru.RSLN, ru.Links = RSLN{}, nil
+	if len(all.Links) > 0 {
+		ru.RSLN = all.Links[0]
+	}
+	if len(all.Links) > 1 {
+		ru.Links = all.Links[1:]
+	}
+	return nil
 }
 
 // IndexDef holds those items defined as making up the resource list index data set
 type IndexDef struct {
-	Loc     string `xml:"loc"`     // mandatory
-	LastMod string `xml:"lastmod"` // optional
-	RSMD    RSMD   `xml:"md"`      // optional
+	Loc     string `xml:"loc"`               // mandatory
+	LastMod string `xml:"lastmod,omitempty"` // optional
+	RSMD    RSMD   `xml:"md"`                // optional
 }
 
 // String implements the stringer interface for IndexDef ensuring consistent printing of values
@@ -104,6 +183,7 @@ func (id IndexDef) String() string {
 type RSLN struct {
 	Rel  string `xml:"rel,attr"`
 	Href string `xml:"href,attr"`
+	Type string `xml:"type,attr"` // optional, the media type of the linked resource
 }
 
 // String implements the stringer interface for RSLN ensuring consistent printing of values
@@ -111,6 +191,18 @@ func (rsln RSLN) String() string {
 	return fmt.Sprintf("Rel: %s HREF: %s", rsln.Rel, rsln.Href)
 }
 
+// MarshalXML writes the link as an rs:ln element. An empty link is omitted entirely.
+func (rsln RSLN) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if rsln == (RSLN{}) {
+		return nil
+	}
+	el := xml.StartElement{Name: xml.Name{Local: "rs:ln"}}
+	el.Attr = appendAttr(el.Attr, "rel", rsln.Rel)
+	el.Attr = appendAttr(el.Attr, "href", rsln.Href)
+	el.Attr = appendAttr(el.Attr, "type", rsln.Type)
+	return writeEmptyElement(e, el)
+}
+
 // RSMD is the namespaced md values defined in the resourcesync protocol.
 // Not all values are present in all cases.
 type RSMD struct {
@@ -167,3 +259,38 @@ func (rsmd RSMD) String() string {
 	}
 	return strings.TrimSpace(sb.String())
 }
+
+// MarshalXML writes the metadata as an rs:md element, only non empty attributes are written.
+// An empty RSMD is omitted entirely.
+func (rsmd RSMD) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if rsmd == (RSMD{}) {
+		return nil
+	}
+	el := xml.StartElement{Name: xml.Name{Local: "rs:md"}}
+	el.Attr = appendAttr(el.Attr, "capability", rsmd.Capability)
+	el.Attr = appendAttr(el.Attr, "at", rsmd.At)
+	el.Attr = appendAttr(el.Attr, "completed", rsmd.Completed)
+	el.Attr = appendAttr(el.Attr, "type", rsmd.Type)
+	el.Attr = appendAttr(el.Attr, "hash", rsmd.Hash)
+	el.Attr = appendAttr(el.Attr, "length", rsmd.Length)
+	el.Attr = appendAttr(el.Attr, "from", rsmd.From)
+	el.Attr = appendAttr(el.Attr, "until", rsmd.Until)
+	el.Attr = appendAttr(el.Attr, "change", rsmd.Change)
+	el.Attr = appendAttr(el.Attr, "datetime", rsmd.DateTime)
+	el.Attr = appendAttr(el.Attr, "path", rsmd.Path)
+	return writeEmptyElement(e, el)
+}
+
+func appendAttr(attrs []xml.Attr, name, value string) []xml.Attr {
+	if value == "" {
+		return attrs
+	}
+	return append(attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+}
+
+func writeEmptyElement(e *xml.Encoder, el xml.StartElement) error {
+	if err := e.EncodeToken(el); err != nil {
+		return err
+	}
+	return e.EncodeToken(el.End())
+}
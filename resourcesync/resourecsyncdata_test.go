@@ -3,6 +3,7 @@ package resourcesync
 import (
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
 )
 
@@ -48,3 +49,21 @@ Loc:
 	assert.Equal(t, exp, got)
 
 }
+
+// TestMarshalRoundTrip ensures documents written by MarshalXML parse back to the same data
+func TestMarshalRoundTrip(t *testing.T) {
+	rs := &ResourceSync{}
+	for _, exp := range []*ResourceData{expListRD, expIndexRD, expCapabilityRD, expChangeListRD} {
+		var data []byte
+		var err error
+		if exp.RL != nil {
+			data, err = Marshal(exp.RL)
+		} else {
+			data, err = Marshal(exp.RLI)
+		}
+		require.Nil(t, err)
+		got, err := rs.Parse(data)
+		require.Nil(t, err)
+		assert.Equal(t, exp, got)
+	}
+}
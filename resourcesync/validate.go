@@ -0,0 +1,130 @@
+package resourcesync
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Validate checks parsed data against the rules of the ResourceSync and Sitemap specifications that Parse does not
+// enforce: entries must have absolute http or https locations, datetimes, hashes and lengths must be well formed,
+// change list entries must give a valid change and a list may hold at most MaxListEntries entries, without
+// duplicates. Every problem found is returned; a nil result means the data is valid.
+func Validate(rd *ResourceData) []error {
+	var problems []error
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+	md := rd.Metadata()
+	validateMetadata(md, "document", report)
+
+	if rd.RLI != nil {
+		if len(rd.RLI.IndexSet) > MaxListEntries {
+			report("index holds %d entries, more than the limit of %d", len(rd.RLI.IndexSet), MaxListEntries)
+		}
+		seen := map[string]bool{}
+		for _, index := range rd.RLI.IndexSet {
+			loc := strings.TrimSpace(index.Loc)
+			validateLoc(loc, report)
+			if seen[loc] {
+				report("%q is listed more than once", loc)
+			}
+			seen[loc] = true
+			validateDateTime(index.LastMod, loc, "lastmod", report)
+			validateMetadata(index.RSMD, fmt.Sprintf("%q", loc), report)
+		}
+		return problems
+	}
+	if rd.RL == nil {
+		report("no data")
+		return problems
+	}
+	if len(rd.RL.URLSet) > MaxListEntries {
+		report("list holds %d entries, more than the limit of %d", len(rd.RL.URLSet), MaxListEntries)
+	}
+	isChangeList := md.Capability == changeList || md.Capability == changeDump ||
+		md.Capability == changeListNotification || md.Capability == changedumpManifest
+	seen := map[string]bool{}
+	for _, ru := range rd.RL.URLSet {
+		loc := strings.TrimSpace(ru.Loc)
+		validateLoc(loc, report)
+		// a change list may record several changes to one resource
+		if seen[loc] && !isChangeList {
+			report("%q is listed more than once", loc)
+		}
+		seen[loc] = true
+		validateDateTime(ru.LastMod, loc, "lastmod", report)
+		validateMetadata(ru.RSMD, fmt.Sprintf("%q", loc), report)
+		if isChangeList {
+			switch ru.RSMD.Change {
+			case ChangeCreated, ChangeUpdated, ChangeDeleted:
+			default:
+				report("%q has invalid change %q", loc, ru.RSMD.Change)
+			}
+		}
+		for _, ln := range ru.allLinks() {
+			if ln.Href != "" {
+				validateLoc(strings.TrimSpace(ln.Href), report)
+			}
+		}
+	}
+	return problems
+}
+
+// validateLoc checks the location is an absolute http or https URL
+func validateLoc(loc string, report func(string, ...interface{})) {
+	u, err := url.Parse(loc)
+	if err != nil {
+		report("invalid location %q: %v", loc, err)
+		return
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		report("location %q is not an absolute http or https URL", loc)
+	}
+}
+
+// validateMetadata checks the datetime, hash and length attributes of an rs:md element
+func validateMetadata(md RSMD, where string, report func(string, ...interface{})) {
+	validateDateTime(md.At, where, "at", report)
+	validateDateTime(md.Completed, where, "completed", report)
+	validateDateTime(md.From, where, "from", report)
+	validateDateTime(md.Until, where, "until", report)
+	validateDateTime(md.DateTime, where, "datetime", report)
+	if md.Length != "" {
+		if n, err := strconv.ParseInt(strings.TrimSpace(md.Length), 10, 64); err != nil || n < 0 {
+			report("%s has invalid length %q", where, md.Length)
+		}
+	}
+	for _, h := range strings.Fields(md.Hash) {
+		parts := strings.SplitN(h, ":", 2)
+		size, ok := digestLength(parts[0])
+		if len(parts) != 2 || !ok {
+			report("%s has unsupported hash %q", where, h)
+			continue
+		}
+		if _, err := hex.DecodeString(parts[1]); err != nil || len(parts[1]) != size {
+			report("%s has malformed hash %q", where, h)
+		}
+	}
+}
+
+func validateDateTime(value, where, attr string, report func(string, ...interface{})) {
+	if value == "" {
+		return
+	}
+	if _, err := ParseDateTime(value); err != nil {
+		report("%s has invalid %s: %v", where, attr, err)
+	}
+}
+
+// digestLength is the number of hex digits in a digest made by the named algorithm, ok is false if it is unsupported
+func digestLength(algorithm string) (int, bool) {
+	for _, alg := range hashAlgorithms {
+		if alg.name == strings.ToLower(algorithm) {
+			return alg.new().Size() * 2, true
+		}
+	}
+	return 0, false
+}
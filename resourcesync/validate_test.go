@@ -0,0 +1,69 @@
+package resourcesync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	testData := []struct {
+		tag      string
+		feed     string
+		expCount int
+	}{
+		{tag: "valid list", feed: validList, expCount: 0},
+		{tag: "invalid list", feed: invalidList, expCount: 6},
+		{tag: "valid change list", feed: validChangeList, expCount: 0},
+		{tag: "invalid change list", feed: invalidChangeList, expCount: 1},
+		{tag: "invalid index", feed: invalidIndex, expCount: 2},
+	}
+	rs := &ResourceSync{}
+	for _, td := range testData {
+		rd, err := rs.Parse([]byte(td.feed))
+		require.Nil(t, err, td.tag)
+		problems := Validate(rd)
+		assert.Len(t, problems, td.expCount, "%s: %v", td.tag, problems)
+	}
+}
+
+//
+// Test Data
+//
+
+const validList = `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:md capability="resourcelist" at="2019-01-02T10:00:00Z"/>
+	<url>
+		<loc>
+			http://example.com/1.pdf
+		</loc>
+		<lastmod>2019-01-01T10:00:00</lastmod>
+		<rs:md hash="md5:1e0d5cb8ef6ba40c99b14c0237be735e sha-256:854f61290e2e197a11bc91063afce22e43f8ccc655237050ace766adc68dc784" length="8876" type="application/pdf"/>
+		<rs:ln rel="describedby" href="http://example.com/1.json"/>
+	</url>
+</urlset>`
+
+// invalidList has a relative loc, bad lastmod, bad length, unsupported and malformed hashes and a duplicate
+const invalidList = `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:md capability="resourcelist"/>
+	<url><loc>/relative.pdf</loc><lastmod>yesterday</lastmod></url>
+	<url><loc>http://example.com/2</loc><rs:md length="-1" hash="crc32:abcd md5:xyz"/></url>
+	<url><loc>http://example.com/2</loc></url>
+</urlset>`
+
+const validChangeList = `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:md capability="changelist" from="2019-01-01T00:00:00Z" until="2019-01-02T00:00:00Z"/>
+	<url><loc>http://example.com/1</loc><rs:md change="created" datetime="2019-01-01T10:00:00Z"/></url>
+	<url><loc>http://example.com/1</loc><rs:md change="deleted" datetime="2019-01-01T11:00:00Z"/></url>
+</urlset>`
+
+const invalidChangeList = `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:md capability="changelist" from="2019-01-01T00:00:00Z"/>
+	<url><loc>http://example.com/1</loc><rs:md change="moved" datetime="2019-01-01T10:00:00Z"/></url>
+</urlset>`
+
+const invalidIndex = `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:md capability="resourcelist" at="not a date"/>
+	<sitemap><loc>ftp://example.com/resourcelist_0001.xml</loc></sitemap>
+</sitemapindex>`
@@ -0,0 +1,64 @@
+package resourcesync
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrSkipList can be returned from a WalkFunc to stop visiting the entries of the current list, the walk carries
+// on with the next list in the index.
+var ErrSkipList = errors.New("skip the rest of this list")
+
+// WalkFunc is called for every entry visited by Walk
+type WalkFunc func(ru ResourceURL) error
+
+// Walk fetches target and calls fn for every entry of the resource list or change list found. Indexes are followed
+// so fn sees the entries of every list they reference, in order. Only entries matching all of the filters are
+// passed to fn, and only the lists of an index matching all of rs.IndexFilters are fetched. If fn returns an error,
+// other than ErrSkipList, the walk stops and that error is returned.
+func (rs *ResourceSync) Walk(target string, fn WalkFunc, filters ...Filter) error {
+	rd, err := rs.Process(target)
+	if err != nil {
+		return err
+	}
+	return rs.WalkData(rd, fn, filters...)
+}
+
+// WalkData is Walk for data that has already been fetched, referenced lists are still fetched as they are reached
+func (rs *ResourceSync) WalkData(rd *ResourceData, fn WalkFunc, filters ...Filter) error {
+	if rd.RLI != nil {
+		for _, index := range rd.RLI.IndexSet {
+			if !rs.walkIndex(index) {
+				continue
+			}
+			if err := rs.Walk(strings.TrimSpace(index.Loc), fn, filters...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	match := All(filters...)
+	for _, ru := range rd.RL.URLSet {
+		if !match(ru) {
+			continue
+		}
+		err := fn(ru)
+		if err == ErrSkipList {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkIndex reports if the list an index references matches all of the IndexFilters
+func (rs *ResourceSync) walkIndex(index IndexDef) bool {
+	for _, f := range rs.IndexFilters {
+		if !f(index) {
+			return false
+		}
+	}
+	return true
+}
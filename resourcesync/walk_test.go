@@ -0,0 +1,123 @@
+package resourcesync
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nathj07/go-resourcesync/fetcher"
+)
+
+func TestWalk(t *testing.T) {
+	server := walkServer()
+	defer server.Close()
+	rs := New(&fetcher.BasicRSFetcher{})
+
+	var visited []string
+	err := rs.Walk(server.URL+"/resourcelist-index.xml", func(ru ResourceURL) error {
+		visited = append(visited, strings.TrimSpace(ru.Loc))
+		return nil
+	})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"http://example.com/1", "http://example.com/2", "http://example.com/3"}, visited)
+}
+
+func TestWalkSkipList(t *testing.T) {
+	server := walkServer()
+	defer server.Close()
+	rs := New(&fetcher.BasicRSFetcher{})
+
+	var visited []string
+	err := rs.Walk(server.URL+"/resourcelist-index.xml", func(ru ResourceURL) error {
+		visited = append(visited, strings.TrimSpace(ru.Loc))
+		return ErrSkipList
+	})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"http://example.com/1", "http://example.com/3"}, visited)
+}
+
+func TestWalkStops(t *testing.T) {
+	server := walkServer()
+	defer server.Close()
+	rs := New(&fetcher.BasicRSFetcher{})
+
+	stop := errors.New("stop")
+	count := 0
+	err := rs.Walk(server.URL+"/resourcelist-index.xml", func(ru ResourceURL) error {
+		count++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestWalkFiltered(t *testing.T) {
+	server := walkServer()
+	defer server.Close()
+	rs := New(&fetcher.BasicRSFetcher{})
+
+	var visited []string
+	err := rs.Walk(server.URL+"/resourcelist-index.xml", func(ru ResourceURL) error {
+		visited = append(visited, strings.TrimSpace(ru.Loc))
+		return nil
+	}, Not(URLPrefix("http://example.com/2")))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"http://example.com/1", "http://example.com/3"}, visited)
+}
+
+func TestWalkIndexFiltered(t *testing.T) {
+	var requested []string
+	server := walkServer(&requested)
+	defer server.Close()
+	rs := New(&fetcher.BasicRSFetcher{})
+	rs.IndexFilters = []IndexFilter{IndexURLPattern(regexp.MustCompile(`_0001\.xml$`))}
+
+	var visited []string
+	err := rs.Walk(server.URL+"/resourcelist-index.xml", func(ru ResourceURL) error {
+		visited = append(visited, strings.TrimSpace(ru.Loc))
+		return nil
+	})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"http://example.com/3"}, visited)
+	assert.Equal(t, []string{"/resourcelist-index.xml", "/resourcelist_0001.xml"}, requested,
+		"the pruned list is never fetched")
+}
+
+// walkServer serves an index referencing two resource lists, recording the paths requested if requested is given
+func walkServer(requested ...*[]string) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, paths := range requested {
+			*paths = append(*paths, r.URL.Path)
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	mux.HandleFunc("/resourcelist-index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:md capability="resourcelist"/>
+	<sitemap><loc>%[1]s/resourcelist_0000.xml</loc></sitemap>
+	<sitemap><loc>%[1]s/resourcelist_0001.xml</loc></sitemap>
+</sitemapindex>`, server.URL)
+	})
+	mux.HandleFunc("/resourcelist_0000.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:md capability="resourcelist"/>
+	<url><loc>http://example.com/1</loc></url>
+	<url><loc>http://example.com/2</loc></url>
+</urlset>`)
+	})
+	mux.HandleFunc("/resourcelist_0001.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:rs="http://www.openarchives.org/rs/terms/">
+	<rs:md capability="resourcelist"/>
+	<url><loc>http://example.com/3</loc></url>
+</urlset>`)
+	})
+	return server
+}
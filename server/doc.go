@@ -0,0 +1,5 @@
+// package server serves ResourceSync documents over HTTP. The Handler reads the documents from any http.FileSystem,
+// such as the OutDir written by a resourcesync.Publisher, and serves them with the correct content types, caching
+// headers and gzip compression where the client supports it.
+
+package server
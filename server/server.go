@@ -0,0 +1,164 @@
+package server
+
+import (
+	"compress/gzip"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+// xmlContentType is served for all ResourceSync documents, including the extension-less source description
+const xmlContentType = "application/xml; charset=utf-8"
+
+// Handler is an http.Handler serving ResourceSync documents from Store.
+// Requests are resolved against the root of Store, so /.well-known/resourcesync is served from the
+// resourcesync.SourceDescriptionFile written by a Publisher.
+type Handler struct {
+	Store http.FileSystem
+}
+
+// New is the simplest way to instantiate a ready to use Handler
+func New(store http.FileSystem) *Handler {
+	return &Handler{
+		Store: store,
+	}
+}
+
+// NewDirHandler returns a Handler serving the documents in a local directory, such as a Publisher OutDir
+func NewDirHandler(dir string) *Handler {
+	return New(http.Dir(dir))
+}
+
+// ServeHTTP serves the requested document. Only GET and HEAD are supported and directories are never listed.
+// Conditional and range requests are honoured; compressible documents are gzipped if the client accepts it.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	name := path.Clean("/" + r.URL.Path)
+	f, err := h.Store.Open(name)
+	if err != nil {
+		h.serveError(w, err)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		h.serveError(w, err)
+		return
+	}
+	if info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	contentType := contentTypeFor(name)
+	w.Header().Set("Content-Type", contentType)
+	etag := fmt.Sprintf("%x-%x", info.Size(), info.ModTime().UnixNano())
+	if compressible(contentType) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if acceptsGzip(r) {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("ETag", `"`+etag+`-gzip"`)
+			// ranges apply to the encoded body, which we cannot seek, so the whole document is always sent
+			r.Header.Del("Range")
+			gw := &gzipResponseWriter{ResponseWriter: w}
+			defer gw.Close()
+			http.ServeContent(gw, r, name, info.ModTime(), f)
+			return
+		}
+	}
+	w.Header().Set("ETag", `"`+etag+`"`)
+	http.ServeContent(w, r, name, info.ModTime(), f)
+}
+
+func (h *Handler) serveError(w http.ResponseWriter, err error) {
+	switch {
+	case os.IsNotExist(err):
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	case os.IsPermission(err):
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// contentTypeFor determines the content type from the document name, ResourceSync documents are always XML
+func contentTypeFor(name string) string {
+	if strings.TrimPrefix(name, "/") == resourcesync.SourceDescriptionFile {
+		return xmlContentType
+	}
+	ext := path.Ext(name)
+	if ext == ".xml" {
+		return xmlContentType
+	}
+	if ct := mime.TypeByExtension(ext); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// compressible reports if the content type is worth gzipping, the dump packages are already compressed
+func compressible(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/") ||
+		strings.HasPrefix(contentType, "application/xml") ||
+		strings.HasPrefix(contentType, "application/json")
+}
+
+// acceptsGzip reports if gzip is listed in the Accept-Encoding header, and not explicitly refused with q=0
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(enc, ";")
+		if strings.TrimSpace(parts[0]) != "gzip" {
+			continue
+		}
+		for _, param := range parts[1:] {
+			if q := strings.Replace(param, " ", "", -1); q == "q=0" || q == "q=0.0" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipResponseWriter compresses the body as it is written. The gzip stream is only started on the first write so
+// bodiless responses, such as 304 Not Modified, are left untouched.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz *gzip.Writer
+}
+
+func (gw *gzipResponseWriter) WriteHeader(status int) {
+	gw.Header().Del("Content-Length")
+	if status != http.StatusOK {
+		// errors and not modified responses are not compressed
+		gw.Header().Del("Content-Encoding")
+	}
+	gw.ResponseWriter.WriteHeader(status)
+}
+
+func (gw *gzipResponseWriter) Write(b []byte) (int, error) {
+	if gw.Header().Get("Content-Encoding") != "gzip" {
+		return gw.ResponseWriter.Write(b)
+	}
+	if gw.gz == nil {
+		gw.gz = gzip.NewWriter(gw.ResponseWriter)
+	}
+	return gw.gz.Write(b)
+}
+
+// Close flushes any compressed data still buffered
+func (gw *gzipResponseWriter) Close() error {
+	if gw.gz == nil {
+		return nil
+	}
+	return gw.gz.Close()
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nathj07/go-resourcesync/fetcher"
+	"github.com/nathj07/go-resourcesync/resourcesync"
+)
+
+func TestHandlerServesPublishedDocuments(t *testing.T) {
+	server, cleanup := publishedServer(t)
+	defer cleanup()
+
+	rs := resourcesync.New(&fetcher.BasicRSFetcher{})
+	rd, err := rs.Process(server.URL + "/.well-known/resourcesync")
+	require.Nil(t, err)
+	assert.Equal(t, resourcesync.Description, rd.RType)
+
+	rd, err = rs.Process(rd.RL.URLSet[0].Loc)
+	require.Nil(t, err)
+	assert.Equal(t, resourcesync.Capability, rd.RType)
+
+	rd, err = rs.Process(rd.RL.URLSet[0].Loc)
+	require.Nil(t, err)
+	assert.Equal(t, resourcesync.List, rd.RType)
+	assert.Len(t, rd.RL.URLSet, 2)
+}
+
+func TestHandlerHeaders(t *testing.T) {
+	server, cleanup := publishedServer(t)
+	defer cleanup()
+
+	type testData struct {
+		tag            string
+		path           string
+		expStatus      int
+		expContentType string
+	}
+	testTable := []testData{
+		{
+			tag:            "SOURCE-DESCRIPTION",
+			path:           "/.well-known/resourcesync",
+			expStatus:      http.StatusOK,
+			expContentType: "application/xml; charset=utf-8",
+		},
+		{
+			tag:            "CAPABILITY-LIST",
+			path:           "/capabilitylist.xml",
+			expStatus:      http.StatusOK,
+			expContentType: "application/xml; charset=utf-8",
+		},
+		{
+			tag:            "DUMP",
+			path:           "/resourcedump_0000.zip",
+			expStatus:      http.StatusOK,
+			expContentType: "application/zip",
+		},
+		{
+			tag:       "DIRECTORY",
+			path:      "/.well-known/",
+			expStatus: http.StatusNotFound,
+		},
+		{
+			tag:       "MISSING",
+			path:      "/missing.xml",
+			expStatus: http.StatusNotFound,
+		},
+	}
+	for _, td := range testTable {
+		t.Run(td.tag, func(t *testing.T) {
+			res, err := http.Get(server.URL + td.path)
+			require.Nil(t, err)
+			res.Body.Close()
+			assert.Equal(t, td.expStatus, res.StatusCode)
+			if td.expStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, td.expContentType, res.Header.Get("Content-Type"))
+			assert.NotEmpty(t, res.Header.Get("ETag"))
+			assert.NotEmpty(t, res.Header.Get("Last-Modified"))
+		})
+	}
+}
+
+func TestHandlerConditionalRequest(t *testing.T) {
+	server, cleanup := publishedServer(t)
+	defer cleanup()
+
+	first, err := http.Get(server.URL + "/resourcelist.xml")
+	require.Nil(t, err)
+	first.Body.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/resourcelist.xml", nil)
+	require.Nil(t, err)
+	req.Header.Set("If-None-Match", first.Header.Get("ETag"))
+	res, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+
+	req.Header.Del("If-None-Match")
+	req.Header.Set("If-Modified-Since", first.Header.Get("Last-Modified"))
+	res, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+}
+
+func TestHandlerGzip(t *testing.T) {
+	server, cleanup := publishedServer(t)
+	defer cleanup()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/resourcelist.xml", nil)
+	require.Nil(t, err)
+	req.Header.Set("Accept-Encoding", "identity")
+	plain, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	exp, err := ioutil.ReadAll(plain.Body)
+	plain.Body.Close()
+	require.Nil(t, err)
+
+	// setting the header ourselves stops the transport transparently decompressing the response
+	req, err = http.NewRequest(http.MethodGet, server.URL+"/resourcelist.xml", nil)
+	require.Nil(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+	res, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+	assert.NotEqual(t, plain.Header.Get("ETag"), res.Header.Get("ETag"))
+	gz, err := gzip.NewReader(res.Body)
+	require.Nil(t, err)
+	got, err := ioutil.ReadAll(gz)
+	require.Nil(t, err)
+	assert.Equal(t, exp, got)
+
+	req, err = http.NewRequest(http.MethodGet, server.URL+"/resourcedump_0000.zip", nil)
+	require.Nil(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+	res, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	res.Body.Close()
+	assert.Empty(t, res.Header.Get("Content-Encoding"))
+}
+
+func TestHandlerMethodNotAllowed(t *testing.T) {
+	server, cleanup := publishedServer(t)
+	defer cleanup()
+
+	res, err := http.Post(server.URL+"/resourcelist.xml", "text/plain", nil)
+	require.Nil(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+	assert.Equal(t, "GET, HEAD", res.Header.Get("Allow"))
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	testTable := map[string]bool{
+		"":                  false,
+		"gzip":              true,
+		"deflate, gzip":     true,
+		"gzip;q=0.8, br":    true,
+		"gzip; q=0":         false,
+		"br, identity":      false,
+		"x-gzip, identity ": false,
+	}
+	for header, exp := range testTable {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", header)
+		assert.Equal(t, exp, acceptsGzip(r), header)
+	}
+}
+
+// publishedServer publishes a resource list and resource dump and starts a server for them.
+// The returned func stops the server and removes the published files.
+func publishedServer(t *testing.T) (*httptest.Server, func()) {
+	out, err := ioutil.TempDir("", "rs-server")
+	require.Nil(t, err)
+	server := httptest.NewServer(NewDirHandler(out))
+	p := resourcesync.NewPublisher(server.URL, out)
+	resources := []resourcesync.ResourceURL{
+		{Loc: "http://example.com/1.pdf", RSMD: resourcesync.RSMD{Type: "application/pdf"}},
+		{Loc: "http://example.com/2.pdf", RSMD: resourcesync.RSMD{Type: "application/pdf"}},
+	}
+	require.Nil(t, p.Publish(resourcesync.NewSliceIterator(resources)))
+	require.Nil(t, p.PublishDirDump(out, "http://example.com"))
+	return server, func() {
+		server.Close()
+		os.RemoveAll(out)
+	}
+}